@@ -0,0 +1,817 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package apitest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/yi-fan-song/space-kraken/api"
+)
+
+const codeBadRequest = http.StatusBadRequest
+
+type shipRequest struct {
+	ShipID   string `json:"shipId"`
+	ToShipID string `json:"toShipId"`
+	Good     string `json:"good"`
+	Quantity int    `json:"quantity"`
+}
+
+func (s *Server) handleStatus(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.GameStatus{Status: api.OkStatusMessage}
+}
+
+func (s *Server) handleCreateUser(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	username := params["username"]
+	if _, ok := s.users[username]; ok {
+		return apiError(http.StatusConflict, http.StatusConflict, "Username has already been claimed.")
+	}
+
+	u = s.newUser(username)
+	return http.StatusCreated, api.CreatedUser{Token: u.token, User: u.inner()}
+}
+
+func (s *Server) handleFetchUser(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.FetchedUser{User: u.inner()}
+}
+
+func (s *Server) handleAvailableLoans(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.AvailableLoans{Loans: s.world.loans}
+}
+
+func (s *Server) handleLoans(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.Loans{Loans: u.inner().Loans}
+}
+
+func (s *Server) handleTakeLoan(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body struct {
+		Type string `json:"type"`
+	}
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	var available *api.AvailableLoan
+	for i := range s.world.loans {
+		if s.world.loans[i].Type == body.Type {
+			available = &s.world.loans[i]
+		}
+	}
+	if available == nil {
+		return apiError(http.StatusUnprocessableEntity, codeBadRequest, "Loan type is not valid.")
+	}
+	for _, loan := range u.loans {
+		if loan.Status == "CURRENT" {
+			return apiError(http.StatusUnprocessableEntity, codeBadRequest, "Only one loan can be active at a time.")
+		}
+	}
+
+	loan := &api.Loan{
+		ID:              s.newID(),
+		Type:            available.Type,
+		Status:          "CURRENT",
+		Due:             time.Now().AddDate(0, 0, available.TermInDays).UTC(),
+		RepaymentAmount: available.Amount * int64(100+available.Rate) / 100,
+	}
+	u.loans = append(u.loans, loan)
+	u.credits += available.Amount
+
+	return http.StatusCreated, api.TakenLoan{Credits: u.credits, Loan: *loan}
+}
+
+func (s *Server) handlePayLoan(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	for _, loan := range u.loans {
+		if loan.ID != params["loanId"] {
+			continue
+		}
+		if loan.Status != "CURRENT" {
+			return apiError(http.StatusBadRequest, codeBadRequest, "Loan has already been paid.")
+		}
+		if u.credits < loan.RepaymentAmount {
+			return apiError(http.StatusBadRequest, api.CodeInsufficientFunds, "User has insufficient credits to pay the loan.")
+		}
+		u.credits -= loan.RepaymentAmount
+		loan.Status = "PAID"
+		return http.StatusOK, api.FetchedUser{User: u.inner()}
+	}
+	return apiError(http.StatusNotFound, api.CodeNotFound, "Loan not found.")
+}
+
+func (s *Server) handleShipListings(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	class := r.URL.Query().Get("class")
+	system := params["symbol"]
+
+	listings := []api.ShipListing{}
+	for _, listing := range s.world.listings {
+		if class != "" && listing.Class != class {
+			continue
+		}
+		if system != "" {
+			var locations []api.PurchaseLocation
+			for _, location := range listing.PurchaseLocations {
+				if location.System == system {
+					locations = append(locations, location)
+				}
+			}
+			if len(locations) == 0 {
+				continue
+			}
+			listing.PurchaseLocations = locations
+		}
+		listings = append(listings, listing)
+	}
+	return http.StatusOK, api.ShipListings{ShipListings: listings}
+}
+
+func (s *Server) handleBuyShip(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body struct {
+		Location string `json:"location"`
+		Type     string `json:"type"`
+	}
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	var price int64
+	for _, listing := range s.world.listings {
+		if listing.Type != body.Type {
+			continue
+		}
+		for _, location := range listing.PurchaseLocations {
+			if location.Location == body.Location {
+				price = location.Price
+			}
+		}
+	}
+	if price == 0 {
+		return apiError(http.StatusBadRequest, codeBadRequest, "That ship is not sold at that location.")
+	}
+	if u.credits < price {
+		return apiError(http.StatusBadRequest, api.CodeInsufficientFunds, "User has insufficient credits for transaction.")
+	}
+
+	ship, err := s.newShip(body.Type, body.Location)
+	if err != nil {
+		return apiError(http.StatusBadRequest, codeBadRequest, err.Error())
+	}
+	u.credits -= price
+	u.ships = append(u.ships, ship)
+
+	return http.StatusCreated, api.PurchasedShip{Credits: u.credits, Ship: *ship}
+}
+
+func (s *Server) handleJettison(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body shipRequest
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	ship, ok := u.ship(params["shipId"])
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+	}
+	if !removeCargo(ship, body.Good, body.Quantity, s.world.volume(body.Good)) {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Ship does not have that much cargo.")
+	}
+
+	return http.StatusOK, api.JettisonedCargo{
+		ShipID:            ship.ID,
+		Good:              body.Good,
+		QuantityRemaining: cargoQuantity(ship, body.Good),
+	}
+}
+
+func (s *Server) handleTransferCargo(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body shipRequest
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	from, ok := u.ship(params["shipId"])
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+	}
+	to, ok := u.ship(body.ToShipID)
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+	}
+	if from.Location == "" || from.Location != to.Location {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Ships must be docked at the same location.")
+	}
+
+	volume := s.world.volume(body.Good)
+	if body.Quantity*volume > to.SpaceAvailable {
+		return apiError(http.StatusBadRequest, api.CodeCargoFull, "Ship has insufficient cargo space.")
+	}
+	if !removeCargo(from, body.Good, body.Quantity, volume) {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Ship does not have that much cargo.")
+	}
+	addCargo(to, body.Good, body.Quantity, volume)
+
+	return http.StatusOK, api.TransferedCargo{FromShip: *from, ToShip: *to}
+}
+
+func (s *Server) handleScrapShip(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	for i, ship := range u.ships {
+		if ship.ID != params["shipId"] {
+			continue
+		}
+		if ship.Location == "" {
+			return apiError(http.StatusBadRequest, codeBadRequest, "Ship must be docked to be scrapped.")
+		}
+
+		credits := s.world.shipPrice(ship.Type) / 4
+		u.credits += credits
+		u.ships = append(u.ships[:i], u.ships[i+1:]...)
+		return http.StatusOK, api.ScrappedShip{Success: fmt.Sprintf("Ship scrapped for %d credits.", credits)}
+	}
+	return apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+}
+
+func (s *Server) handleMarketplace(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	if _, ok := s.world.location(params["symbol"]); !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Location not found.")
+	}
+
+	goods := []api.MarketGood{}
+	for _, good := range s.world.markets[params["symbol"]] {
+		goods = append(goods, *good)
+	}
+	return http.StatusOK, api.Marketplace{Marketplace: goods}
+}
+
+func (s *Server) handlePurchaseOrder(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body shipRequest
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	ship, good, status, res := s.orderTarget(u, body)
+	if status != 0 {
+		return status, res
+	}
+	if body.Quantity > good.QuantityAvailable {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Not enough of that good is available.")
+	}
+	if body.Quantity*good.VolumePerUnit > ship.SpaceAvailable {
+		return apiError(http.StatusBadRequest, api.CodeCargoFull, "Ship has insufficient cargo space.")
+	}
+	total := int64(body.Quantity) * good.PurchasePricePerUnit
+	if total > u.credits {
+		return apiError(http.StatusBadRequest, api.CodeInsufficientFunds, "User has insufficient credits for transaction.")
+	}
+
+	u.credits -= total
+	good.QuantityAvailable -= body.Quantity
+	addCargo(ship, good.Symbol, body.Quantity, good.VolumePerUnit)
+
+	return http.StatusCreated, api.PlacedOrder{
+		Credits: u.credits,
+		Order:   api.Order{Good: good.Symbol, Quantity: body.Quantity, PricePerUnit: good.PurchasePricePerUnit, Total: total},
+		Ship:    *ship,
+	}
+}
+
+func (s *Server) handleSellOrder(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body shipRequest
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	ship, good, status, res := s.orderTarget(u, body)
+	if status != 0 {
+		return status, res
+	}
+	if !removeCargo(ship, good.Symbol, body.Quantity, good.VolumePerUnit) {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Ship does not have that much cargo.")
+	}
+
+	total := int64(body.Quantity) * good.SellPricePerUnit
+	u.credits += total
+	good.QuantityAvailable += body.Quantity
+
+	return http.StatusCreated, api.PlacedOrder{
+		Credits: u.credits,
+		Order:   api.Order{Good: good.Symbol, Quantity: body.Quantity, PricePerUnit: good.SellPricePerUnit, Total: total},
+		Ship:    *ship,
+	}
+}
+
+// orderTarget finds the ship and market good of an order, mu must be held.
+func (s *Server) orderTarget(u *user, body shipRequest) (*api.Ship, *api.MarketGood, int, interface{}) {
+	if body.Quantity <= 0 {
+		status, res := apiError(http.StatusBadRequest, codeBadRequest, "Quantity must be positive.")
+		return nil, nil, status, res
+	}
+	ship, ok := u.ship(body.ShipID)
+	if !ok {
+		status, res := apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+		return nil, nil, status, res
+	}
+	if ship.Location == "" {
+		status, res := apiError(http.StatusBadRequest, codeBadRequest, "Ship is in transit.")
+		return nil, nil, status, res
+	}
+	for _, good := range s.world.markets[ship.Location] {
+		if good.Symbol == body.Good {
+			return ship, good, 0, nil
+		}
+	}
+	status, res := apiError(http.StatusBadRequest, codeBadRequest, "That good is not traded at this location.")
+	return nil, nil, status, res
+}
+
+func (s *Server) handleCreateFlightPlan(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body struct {
+		ShipID      string `json:"shipId"`
+		Destination string `json:"destination"`
+	}
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	ship, ok := u.ship(body.ShipID)
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+	}
+	if ship.Location == "" {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Ship is already in transit.")
+	}
+	departure, _ := s.world.location(ship.Location)
+	destination, ok := s.world.location(body.Destination)
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Destination not found.")
+	}
+	if destination.Symbol == departure.Symbol || systemOf(destination.Symbol) != systemOf(departure.Symbol) {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Destination is not valid for this ship.")
+	}
+
+	dist := distance(departure, destination)
+	fuel := dist/10 + 1
+	if !removeCargo(ship, "FUEL", fuel, s.world.volume("FUEL")) {
+		return apiError(http.StatusBadRequest, codeBadRequest, fmt.Sprintf("Ship needs %d fuel for this flight.", fuel))
+	}
+
+	plan := s.startFlight(u, ship, departure.Symbol, destination.Symbol, dist, fuel)
+	return http.StatusCreated, api.FetchedFlightPlan{FlightPlan: plan}
+}
+
+func (s *Server) handleWarpJump(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body struct {
+		ShipID string `json:"shipId"`
+	}
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	ship, ok := u.ship(body.ShipID)
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+	}
+	destination, ok := s.world.wormholes[ship.Location]
+	if !ok {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Ship must be docked at a wormhole to warp.")
+	}
+
+	plan := s.startFlight(u, ship, ship.Location, destination, 50, 0)
+	return http.StatusCreated, api.FetchedFlightPlan{FlightPlan: plan}
+}
+
+// startFlight puts ship in transit, mu must be held.
+func (s *Server) startFlight(u *user, ship *api.Ship, departure string, destination string, dist int, fuel int) api.FlightPlan {
+	now := time.Now().UTC()
+	f := &flight{
+		username: u.username,
+		shipType: ship.Type,
+		plan: api.FlightPlan{
+			ID:            s.newID(),
+			ShipID:        ship.ID,
+			Departure:     departure,
+			Destination:   destination,
+			Distance:      dist,
+			FuelConsumed:  fuel,
+			FuelRemaining: cargoQuantity(ship, "FUEL"),
+			CreatedAt:     now,
+			ArrivesAt:     now.Add(time.Duration(dist) * s.distanceDur / time.Duration(ship.Speed)),
+		},
+	}
+	s.flights[f.plan.ID] = f
+	ship.Location = ""
+
+	return f.current()
+}
+
+func (s *Server) handleFetchFlightPlan(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	f, ok := s.flights[params["planId"]]
+	if !ok || f.username != u.username {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Flight plan not found.")
+	}
+	return http.StatusOK, api.FetchedFlightPlan{FlightPlan: f.current()}
+}
+
+func (s *Server) handleSystemFlightPlans(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	plans := []api.SystemFlightPlan{}
+	for _, f := range s.flights {
+		if f.plan.TerminatedAt != nil || systemOf(f.plan.Departure) != params["symbol"] {
+			continue
+		}
+		plans = append(plans, api.SystemFlightPlan{
+			ID:          f.plan.ID,
+			ShipID:      f.plan.ShipID,
+			ShipType:    f.shipType,
+			Username:    f.username,
+			Departure:   f.plan.Departure,
+			Destination: f.plan.Destination,
+			CreatedAt:   f.plan.CreatedAt,
+			ArrivesAt:   f.plan.ArrivesAt,
+		})
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID < plans[j].ID })
+	return http.StatusOK, api.SystemFlightPlans{FlightPlans: plans}
+}
+
+// current returns the plan with its remaining time filled in.
+func (f *flight) current() api.FlightPlan {
+	plan := f.plan
+	if remaining := time.Until(plan.ArrivesAt); remaining > 0 && plan.TerminatedAt == nil {
+		plan.TimeRemainingInSeconds = int(remaining.Seconds())
+	}
+	return plan
+}
+
+// settleFlights docks ships whose flights have arrived, mu must be held.
+func (s *Server) settleFlights() {
+	now := time.Now().UTC()
+	for _, f := range s.flights {
+		if f.plan.TerminatedAt != nil || now.Before(f.plan.ArrivesAt) {
+			continue
+		}
+		arrivedAt := f.plan.ArrivesAt
+		f.plan.TerminatedAt = &arrivedAt
+
+		u, ok := s.users[f.username]
+		if !ok {
+			continue
+		}
+		if ship, ok := u.ship(f.plan.ShipID); ok {
+			location, _ := s.world.location(f.plan.Destination)
+			ship.Location = location.Symbol
+			ship.X = location.X
+			ship.Y = location.Y
+		}
+	}
+}
+
+func (s *Server) handleSystems(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.Systems{Systems: s.world.systems}
+}
+
+func (s *Server) handleLocations(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	locationType := r.URL.Query().Get("type")
+	for _, system := range s.world.systems {
+		if system.Symbol != params["symbol"] {
+			continue
+		}
+		locations := []api.Location{}
+		for _, location := range system.Locations {
+			if locationType == "" || location.Type == locationType {
+				locations = append(locations, location)
+			}
+		}
+		return http.StatusOK, api.Locations{Locations: locations}
+	}
+	return apiError(http.StatusNotFound, api.CodeNotFound, "System not found.")
+}
+
+func (s *Server) handleLocation(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	location, ok := s.world.location(params["symbol"])
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Location not found.")
+	}
+	return http.StatusOK, api.FetchedLocation{Location: location}
+}
+
+func (s *Server) handleDockedShips(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	if _, ok := s.world.location(params["symbol"]); !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Location not found.")
+	}
+
+	var res api.LocationShips
+	res.Location.Ships = []api.DockedShip{}
+	for _, owner := range s.sortedUsers() {
+		for _, ship := range owner.ships {
+			if ship.Location == params["symbol"] {
+				res.Location.Ships = append(res.Location.Ships, api.DockedShip{
+					ShipID:   ship.ID,
+					Username: owner.username,
+					ShipType: ship.Type,
+				})
+			}
+		}
+	}
+	return http.StatusOK, res
+}
+
+func (s *Server) handleStructures(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	structures := []api.Structure{}
+	for _, structure := range u.structures {
+		structures = append(structures, *structure)
+	}
+	return http.StatusOK, api.Structures{Structures: structures}
+}
+
+func (s *Server) handleCreateStructure(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body struct {
+		Location string `json:"location"`
+		Type     string `json:"type"`
+	}
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	location, ok := s.world.location(body.Location)
+	if !ok {
+		return apiError(http.StatusNotFound, api.CodeNotFound, "Location not found.")
+	}
+	t, ok := s.world.structureType(body.Type)
+	if !ok {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Structure type is not valid.")
+	}
+	allowed := false
+	for _, locationType := range t.AllowedLocationTypes {
+		allowed = allowed || locationType == location.Type
+	}
+	if !location.AllowsConstruction || !allowed {
+		return apiError(http.StatusBadRequest, codeBadRequest, "That structure cannot be built at this location.")
+	}
+	if u.credits < t.Price {
+		return apiError(http.StatusBadRequest, api.CodeInsufficientFunds, "User has insufficient credits for transaction.")
+	}
+
+	structure := &api.Structure{
+		ID:        s.newID(),
+		Type:      t.Type,
+		Location:  location.Symbol,
+		Active:    true,
+		Status:    "Awaiting materials.",
+		Inventory: []api.Goods{},
+		Consumes:  t.Consumes,
+		Produces:  t.Produces,
+	}
+	for _, good := range append(append([]string{}, t.Consumes...), t.Produces...) {
+		structure.Inventory = append(structure.Inventory, api.Goods{Good: good})
+	}
+	u.credits -= t.Price
+	u.structures = append(u.structures, structure)
+
+	return http.StatusCreated, api.FetchedStructure{Structure: *structure}
+}
+
+func (s *Server) handleDeposit(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body shipRequest
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	ship, structure, status, res := s.structureTarget(u, params["structureId"], body)
+	if status != 0 {
+		return status, res
+	}
+	if !contains(structure.Consumes, body.Good) {
+		return apiError(http.StatusBadRequest, codeBadRequest, "This structure does not accept that good.")
+	}
+	if !removeCargo(ship, body.Good, body.Quantity, s.world.volume(body.Good)) {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Ship does not have that much cargo.")
+	}
+	addInventory(structure, body.Good, body.Quantity)
+
+	return http.StatusOK, api.StructureDeposit{
+		Deposit:   api.Goods{Good: body.Good, Quantity: body.Quantity},
+		Ship:      *ship,
+		Structure: *structure,
+	}
+}
+
+func (s *Server) handleStructureTransfer(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	var body shipRequest
+	if status, res := decode(r, &body); status != 0 {
+		return status, res
+	}
+
+	ship, structure, status, res := s.structureTarget(u, params["structureId"], body)
+	if status != 0 {
+		return status, res
+	}
+	volume := s.world.volume(body.Good)
+	if body.Quantity*volume > ship.SpaceAvailable {
+		return apiError(http.StatusBadRequest, api.CodeCargoFull, "Ship has insufficient cargo space.")
+	}
+	if !addInventory(structure, body.Good, -body.Quantity) {
+		return apiError(http.StatusBadRequest, codeBadRequest, "Structure does not have that much of that good.")
+	}
+	addCargo(ship, body.Good, body.Quantity, volume)
+
+	return http.StatusOK, api.StructureTransfer{
+		Transfer:  api.Goods{Good: body.Good, Quantity: body.Quantity},
+		Ship:      *ship,
+		Structure: *structure,
+	}
+}
+
+// structureTarget finds the ship and structure goods are moved between,
+// mu must be held.
+func (s *Server) structureTarget(u *user, structureID string, body shipRequest) (*api.Ship, *api.Structure, int, interface{}) {
+	if body.Quantity <= 0 {
+		status, res := apiError(http.StatusBadRequest, codeBadRequest, "Quantity must be positive.")
+		return nil, nil, status, res
+	}
+	ship, ok := u.ship(body.ShipID)
+	if !ok {
+		status, res := apiError(http.StatusNotFound, api.CodeNotFound, "Ship not found.")
+		return nil, nil, status, res
+	}
+	for _, structure := range u.structures {
+		if structure.ID != structureID {
+			continue
+		}
+		if ship.Location != structure.Location {
+			status, res := apiError(http.StatusBadRequest, codeBadRequest, "Ship must be docked at the structure's location.")
+			return nil, nil, status, res
+		}
+		return ship, structure, 0, nil
+	}
+	status, res := apiError(http.StatusNotFound, api.CodeNotFound, "Structure not found.")
+	return nil, nil, status, res
+}
+
+func (s *Server) handleGoodTypes(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.GoodTypes{Goods: s.world.goods}
+}
+
+func (s *Server) handleShipTypes(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.ShipTypes{Ships: s.world.shipTypes}
+}
+
+func (s *Server) handleLoanTypes(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.LoanTypes{Loans: s.world.loans}
+}
+
+func (s *Server) handleStructureTypes(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	return http.StatusOK, api.StructureTypes{Structures: s.world.structureTypes}
+}
+
+func (s *Server) handleLeaderboard(r *http.Request, params map[string]string, u *user) (int, interface{}) {
+	token := r.Header.Get("Authorization")
+
+	var ranks []api.NetWorthRank
+	for _, owner := range s.sortedUsers() {
+		netWorth := owner.credits
+		for _, ship := range owner.ships {
+			netWorth += s.world.shipPrice(ship.Type)
+		}
+		ranks = append(ranks, api.NetWorthRank{Username: owner.username, NetWorth: netWorth})
+	}
+	sort.SliceStable(ranks, func(i, j int) bool { return ranks[i].NetWorth > ranks[j].NetWorth })
+
+	var res api.Leaderboard
+	for i := range ranks {
+		ranks[i].Rank = i + 1
+		if token == "Bearer "+s.users[ranks[i].Username].token {
+			res.UserNetWorth = ranks[i]
+		}
+	}
+	if len(ranks) > 10 {
+		ranks = ranks[:10]
+	}
+	res.NetWorth = ranks
+
+	return http.StatusOK, res
+}
+
+// sortedUsers returns the users ordered by username, mu must be held.
+func (s *Server) sortedUsers() []*user {
+	users := make([]*user, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].username < users[j].username })
+	return users
+}
+
+func (u *user) inner() api.InnerUser {
+	inner := api.InnerUser{
+		Username: u.username,
+		Credits:  u.credits,
+		Ships:    []api.Ship{},
+		Loans:    []api.Loan{},
+	}
+	for _, ship := range u.ships {
+		inner.Ships = append(inner.Ships, *ship)
+	}
+	for _, loan := range u.loans {
+		inner.Loans = append(inner.Loans, *loan)
+	}
+	return inner
+}
+
+func (u *user) ship(id string) (*api.Ship, bool) {
+	for _, ship := range u.ships {
+		if ship.ID == id {
+			return ship, true
+		}
+	}
+	return nil, false
+}
+
+func addCargo(ship *api.Ship, good string, quantity int, volume int) {
+	ship.SpaceAvailable -= quantity * volume
+	for i := range ship.Cargo {
+		if ship.Cargo[i].Good == good {
+			ship.Cargo[i].Quantity += quantity
+			ship.Cargo[i].TotalVolume += quantity * volume
+			return
+		}
+	}
+	ship.Cargo = append(ship.Cargo, api.Cargo{Good: good, Quantity: quantity, TotalVolume: quantity * volume})
+}
+
+// removeCargo returns false if the ship doesn't hold quantity of good.
+func removeCargo(ship *api.Ship, good string, quantity int, volume int) bool {
+	if quantity <= 0 {
+		return false
+	}
+	for i := range ship.Cargo {
+		if ship.Cargo[i].Good != good {
+			continue
+		}
+		if ship.Cargo[i].Quantity < quantity {
+			return false
+		}
+		ship.Cargo[i].Quantity -= quantity
+		ship.Cargo[i].TotalVolume -= quantity * volume
+		ship.SpaceAvailable += quantity * volume
+		if ship.Cargo[i].Quantity == 0 {
+			ship.Cargo = append(ship.Cargo[:i], ship.Cargo[i+1:]...)
+		}
+		return true
+	}
+	return false
+}
+
+func cargoQuantity(ship *api.Ship, good string) int {
+	for _, cargo := range ship.Cargo {
+		if cargo.Good == good {
+			return cargo.Quantity
+		}
+	}
+	return 0
+}
+
+// addInventory adds quantity of good to a structure, it returns false if
+// that would leave a negative amount.
+func addInventory(structure *api.Structure, good string, quantity int) bool {
+	for i := range structure.Inventory {
+		if structure.Inventory[i].Good != good {
+			continue
+		}
+		if structure.Inventory[i].Quantity+quantity < 0 {
+			return false
+		}
+		structure.Inventory[i].Quantity += quantity
+		return true
+	}
+	if quantity < 0 {
+		return false
+	}
+	structure.Inventory = append(structure.Inventory, api.Goods{Good: good, Quantity: quantity})
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,423 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+// Package apitest provides an in-process fake of the space traders api for
+// integration tests.
+//
+// The server keeps users, credits, loans, ships, markets, structures and
+// flight plans in memory and can inject faults such as rate limiting,
+// server errors, slow responses and maintenance mode.
+package apitest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yi-fan-song/space-kraken/api"
+)
+
+// MaintenanceMessage is the status returned while in maintenance mode.
+const MaintenanceMessage = "spacetraders is currently undergoing maintenance"
+
+// Fault is an error injected in the response to a single request.
+type Fault struct {
+	// StatusCode is the status to answer with, 0 lets the request through
+	// after Delay
+	StatusCode int
+	// Delay is how long to wait before answering
+	Delay time.Duration
+	// RetryAfter is sent in the Retry-After header of 429 responses
+	RetryAfter time.Duration
+}
+
+// Server is a fake space traders api.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	world       world
+	users       map[string]*user
+	flights     map[string]*flight
+	nextID      int
+	faults      []Fault
+	maintenance bool
+	latency     time.Duration
+	distanceDur time.Duration
+	requests    int
+}
+
+type user struct {
+	username   string
+	token      string
+	credits    int64
+	ships      []*api.Ship
+	loans      []*api.Loan
+	structures []*api.Structure
+}
+
+type flight struct {
+	plan     api.FlightPlan
+	username string
+	shipType string
+}
+
+// NewServer starts a fake api, it should be closed when done.
+func NewServer() *Server {
+	s := &Server{
+		world:       newWorld(),
+		users:       map[string]*user{},
+		flights:     map[string]*flight{},
+		distanceDur: 10 * time.Millisecond,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// CreateUser adds a user with credits and returns its token.
+func (s *Server) CreateUser(username string, credits int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.newUser(username)
+	u.credits = credits
+	return u.token
+}
+
+// Credits returns the credits of username.
+func (s *Server) Credits(username string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users[username]; ok {
+		return u.credits
+	}
+	return 0
+}
+
+// GiveShip adds a ship of shipType docked at location to username and
+// returns its id.
+func (s *Server) GiveShip(username string, shipType string, location string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return "", fmt.Errorf("apitest: no user %s", username)
+	}
+	ship, err := s.newShip(shipType, location)
+	if err != nil {
+		return "", err
+	}
+	u.ships = append(u.ships, ship)
+	return ship.ID, nil
+}
+
+// InjectFaults queues faults, each request consumes the next one.
+func (s *Server) InjectFaults(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, faults...)
+}
+
+// SetMaintenance puts the server in or out of maintenance mode, every
+// request fails with a 503 while in maintenance.
+func (s *Server) SetMaintenance(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maintenance = on
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// SetTimePerDistance sets how long a speed 1 ship takes to fly one unit of
+// distance, flights are short by default so tests don't wait.
+func (s *Server) SetTimePerDistance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.distanceDur = d
+}
+
+// Requests returns the number of requests received, faults included.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// authLevel is the authorization a route needs.
+type authLevel int
+
+const (
+	authNone authLevel = iota
+	// authAny needs the token of any user
+	authAny
+	// authOwner needs the token of the user in the :username parameter
+	authOwner
+)
+
+type handlerFunc func(r *http.Request, params map[string]string, u *user) (int, interface{})
+
+type route struct {
+	method  string
+	pattern []string
+	auth    authLevel
+	handler handlerFunc
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{http.MethodGet, split("/game/status"), authNone, s.handleStatus},
+		{http.MethodPost, split("/users/:username/token"), authNone, s.handleCreateUser},
+		{http.MethodGet, split("/users/:username"), authOwner, s.handleFetchUser},
+
+		{http.MethodGet, split("/game/loans"), authAny, s.handleAvailableLoans},
+		{http.MethodGet, split("/users/:username/loans"), authOwner, s.handleLoans},
+		{http.MethodPost, split("/users/:username/loans"), authOwner, s.handleTakeLoan},
+		{http.MethodPut, split("/users/:username/loans/:loanId"), authOwner, s.handlePayLoan},
+
+		{http.MethodGet, split("/game/ships"), authAny, s.handleShipListings},
+		{http.MethodGet, split("/systems/:symbol/ship-listings"), authAny, s.handleShipListings},
+		{http.MethodPost, split("/users/:username/ships"), authOwner, s.handleBuyShip},
+		{http.MethodPost, split("/users/:username/ships/:shipId/jettison"), authOwner, s.handleJettison},
+		{http.MethodPost, split("/users/:username/ships/:shipId/transfer"), authOwner, s.handleTransferCargo},
+		{http.MethodDelete, split("/users/:username/ships/:shipId"), authOwner, s.handleScrapShip},
+
+		{http.MethodGet, split("/game/locations/:symbol/marketplace"), authAny, s.handleMarketplace},
+		{http.MethodPost, split("/users/:username/purchase-orders"), authOwner, s.handlePurchaseOrder},
+		{http.MethodPost, split("/users/:username/sell-orders"), authOwner, s.handleSellOrder},
+
+		{http.MethodPost, split("/users/:username/flight-plans"), authOwner, s.handleCreateFlightPlan},
+		{http.MethodGet, split("/users/:username/flight-plans/:planId"), authOwner, s.handleFetchFlightPlan},
+		{http.MethodGet, split("/game/systems/:symbol/flight-plans"), authAny, s.handleSystemFlightPlans},
+		{http.MethodPost, split("/users/:username/warp-jump"), authOwner, s.handleWarpJump},
+
+		{http.MethodGet, split("/game/systems"), authAny, s.handleSystems},
+		{http.MethodGet, split("/game/systems/:symbol/locations"), authAny, s.handleLocations},
+		{http.MethodGet, split("/game/locations/:symbol"), authAny, s.handleLocation},
+		{http.MethodGet, split("/game/locations/:symbol/ships"), authAny, s.handleDockedShips},
+
+		{http.MethodGet, split("/users/:username/structures"), authOwner, s.handleStructures},
+		{http.MethodPost, split("/users/:username/structures"), authOwner, s.handleCreateStructure},
+		{http.MethodPost, split("/users/:username/structures/:structureId/deposit"), authOwner, s.handleDeposit},
+		{http.MethodPost, split("/users/:username/structures/:structureId/transfer"), authOwner, s.handleStructureTransfer},
+
+		{http.MethodGet, split("/types/goods"), authAny, s.handleGoodTypes},
+		{http.MethodGet, split("/types/ships"), authAny, s.handleShipTypes},
+		{http.MethodGet, split("/types/loans"), authAny, s.handleLoanTypes},
+		{http.MethodGet, split("/types/structures"), authAny, s.handleStructureTypes},
+
+		{http.MethodGet, split("/game/leaderboard/net-worth"), authAny, s.handleLeaderboard},
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	var fault Fault
+	if len(s.faults) > 0 {
+		fault = s.faults[0]
+		s.faults = s.faults[1:]
+	}
+	delay := s.latency + fault.Delay
+	maintenance := s.maintenance
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case fault.StatusCode == http.StatusTooManyRequests:
+		w.Header().Set("Retry-After", strconv.FormatFloat(fault.RetryAfter.Seconds(), 'f', -1, 64))
+		writeError(w, http.StatusTooManyRequests, api.CodeRateLimited, "Throttle limit reached. Please try again later.")
+		return
+	case fault.StatusCode != 0:
+		writeError(w, fault.StatusCode, fault.StatusCode, http.StatusText(fault.StatusCode))
+		return
+	case maintenance:
+		writeError(w, http.StatusServiceUnavailable, http.StatusServiceUnavailable, MaintenanceMessage)
+		return
+	}
+
+	path := split(r.URL.EscapedPath())
+	for _, route := range s.routes() {
+		if route.method != r.Method {
+			continue
+		}
+		params, ok := match(route.pattern, path)
+		if !ok {
+			continue
+		}
+
+		s.mu.Lock()
+		s.settleFlights()
+		status, body := s.authorize(r, route, params)
+		if status == 0 {
+			var u *user
+			if route.auth == authOwner {
+				u = s.users[params["username"]]
+			}
+			status, body = route.handler(r, params, u)
+		}
+		// bodies share slices with the server state, they are encoded before
+		// other requests can change it
+		buf, err := json.Marshal(body)
+		s.mu.Unlock()
+
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeBody(w, status, buf)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, api.CodeNotFound, "Route not found.")
+}
+
+// authorize returns a status and error body if the request can't go
+// through, mu must be held.
+func (s *Server) authorize(r *http.Request, route route, params map[string]string) (int, interface{}) {
+	if route.auth == authNone {
+		return 0, nil
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+
+	if route.auth == authOwner {
+		u, ok := s.users[params["username"]]
+		if !ok || u.token != token {
+			return apiError(http.StatusUnauthorized, api.CodeUnauthorized, "Token was invalid or missing from the request.")
+		}
+		return 0, nil
+	}
+
+	for _, u := range s.users {
+		if u.token == token {
+			return 0, nil
+		}
+	}
+	return apiError(http.StatusUnauthorized, api.CodeUnauthorized, "Token was invalid or missing from the request.")
+}
+
+// newUser creates a user with a random token, mu must be held.
+func (s *Server) newUser(username string) *user {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+
+	u := &user{
+		username: username,
+		token:    hex.EncodeToString(buf),
+	}
+	s.users[username] = u
+	return u
+}
+
+// newID returns a unique id, mu must be held.
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("ck%08x", s.nextID)
+}
+
+func split(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// match compares an escaped path to a pattern, segments starting with ":"
+// are parameters.
+func match(pattern []string, path []string) (map[string]string, bool) {
+	if len(pattern) != len(path) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, ":") {
+			value, err := url.PathUnescape(path[i])
+			if err != nil {
+				return nil, false
+			}
+			params[segment[1:]] = value
+		} else if segment != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func decode(r *http.Request, v interface{}) (int, interface{}) {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return apiError(http.StatusBadRequest, http.StatusBadRequest, "Invalid request body: "+err.Error())
+	}
+	return 0, nil
+}
+
+func apiError(status int, code int, message string) (int, interface{}) {
+	var body api.ResponseError
+	body.Error.Code = code
+	body.Error.Message = message
+	return status, body
+}
+
+func writeError(w http.ResponseWriter, status int, code int, message string) {
+	status, body := apiError(status, code, message)
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		status = http.StatusInternalServerError
+		buf = []byte(`{"error":{"message":"could not encode the response","code":500}}`)
+	}
+	writeBody(w, status, buf)
+}
+
+// writeBody writes an encoded json body.
+func writeBody(w http.ResponseWriter, status int, buf []byte) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(append(buf, '\n'))
+}
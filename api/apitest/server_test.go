@@ -0,0 +1,252 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package apitest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yi-fan-song/space-kraken/api"
+	"github.com/yi-fan-song/space-kraken/api/apitest"
+	"github.com/yi-fan-song/space-kraken/log"
+)
+
+// newClient points an api client at s, retries are quick so tests don't wait.
+func newClient(s *apitest.Server, username string, token string) api.Client {
+	policy := api.DefaultRetryPolicy
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+
+	logger := log.New(io.Discard, io.Discard, log.Config{})
+	return api.New(username, token, &http.Client{Timeout: 10 * time.Second}, logger,
+		api.WithBaseURL(s.URL),
+		api.WithRateLimit(0, 0),
+		api.WithRetryPolicy(policy),
+	)
+}
+
+func TestAccountOrderAndFlight(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+
+	token := s.CreateUser("kraken", 10000)
+	shipID, err := s.GiveShip("kraken", "JW-MK-I", "OE-PM-TR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(s, "kraken", token)
+	ctx := context.Background()
+
+	account, err := c.FetchAccount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.User.Credits != 10000 || len(account.User.Ships) != 1 || account.User.Ships[0].ID != shipID {
+		t.Fatalf("got account %+v", account.User)
+	}
+
+	order, err := c.PlacePurchaseOrder(ctx, shipID, "FUEL", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Order.Quantity != 20 || order.Credits != 10000-order.Order.Total {
+		t.Errorf("got order %+v with %d credits left", order.Order, order.Credits)
+	}
+	if credits := s.Credits("kraken"); credits != order.Credits {
+		t.Errorf("server has %d credits, order reported %d", credits, order.Credits)
+	}
+
+	plan, err := c.CreateFlightPlan(ctx, shipID, "OE-PM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Departure != "OE-PM-TR" || plan.Destination != "OE-PM" || plan.FuelConsumed == 0 {
+		t.Errorf("got flight plan %+v", plan)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for plan.TerminatedAt == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the flight never arrived")
+		}
+		time.Sleep(10 * time.Millisecond)
+		if plan, err = c.FetchFlightPlan(ctx, plan.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	account, err = c.FetchAccount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location := account.User.Ships[0].Location; location != "OE-PM" {
+		t.Errorf("ship is at %q after the flight, want OE-PM", location)
+	}
+}
+
+func TestRateLimitFault(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+	c := newClient(s, "", "")
+
+	s.InjectFaults(apitest.Fault{StatusCode: http.StatusTooManyRequests, RetryAfter: 200 * time.Millisecond})
+	start := time.Now()
+	status, err := c.FetchStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("retried after %s, Retry-After was 200ms", elapsed)
+	}
+	if status.Status != api.OkStatusMessage || s.Requests() != 2 {
+		t.Errorf("got status %q after %d requests", status.Status, s.Requests())
+	}
+}
+
+func TestServerErrorFault(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+	token := s.CreateUser("kraken", 10000)
+	shipID, err := s.GiveShip("kraken", "JW-MK-I", "OE-PM-TR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(s, "kraken", token)
+	ctx := context.Background()
+
+	// reads are retried
+	s.InjectFaults(apitest.Fault{StatusCode: http.StatusInternalServerError})
+	if _, err := c.FetchAccount(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if requests := s.Requests(); requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+
+	// purchases are not
+	s.InjectFaults(apitest.Fault{StatusCode: http.StatusInternalServerError})
+	_, err = c.PlacePurchaseOrder(ctx, shipID, "FUEL", 20)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got error %v, want a 500", err)
+	}
+	if requests := s.Requests(); requests != 3 {
+		t.Errorf("got %d requests, want 3", requests)
+	}
+	if credits := s.Credits("kraken"); credits != 10000 {
+		t.Errorf("got %d credits after a failed purchase", credits)
+	}
+}
+
+func TestDelayFault(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+	c := newClient(s, "", "")
+
+	s.InjectFaults(apitest.Fault{Delay: 100 * time.Millisecond})
+	start := time.Now()
+	if _, err := c.FetchStatus(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("got a response after %s, the delay was 100ms", elapsed)
+	}
+
+	s.InjectFaults(apitest.Fault{Delay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.FetchStatus(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want the deadline to be exceeded", err)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+	c := newClient(s, "", "")
+	ctx := context.Background()
+
+	s.SetMaintenance(true)
+	_, err := c.FetchStatus(ctx)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got error %v, want a 503", err)
+	}
+	if apiErr.Message != apitest.MaintenanceMessage {
+		t.Errorf("got message %q", apiErr.Message)
+	}
+
+	s.SetMaintenance(false)
+	status, err := c.FetchStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != api.OkStatusMessage {
+		t.Errorf("got status %q", status.Status)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+	token := s.CreateUser("kraken", 100000)
+	shipID, err := s.GiveShip("kraken", "GR-MK-I", "OE-PM-TR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(s, "kraken", token)
+	ctx := context.Background()
+
+	// responses share the ship's cargo with the server, run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if _, err := c.PlacePurchaseOrder(ctx, shipID, "FOOD", 1); err != nil {
+				t.Error(err)
+			}
+		}()
+		for j := 0; j < 2; j++ {
+			go func() {
+				defer wg.Done()
+				if _, err := c.FetchAccount(ctx); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	account, err := c.FetchAccount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cargo := range account.User.Ships[0].Cargo {
+		if cargo.Good == "FOOD" && cargo.Quantity != 50 {
+			t.Errorf("got %d food, want 50", cargo.Quantity)
+		}
+	}
+}
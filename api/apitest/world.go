@@ -0,0 +1,238 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package apitest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/yi-fan-song/space-kraken/api"
+)
+
+// world is the static part of the game along with market stock.
+type world struct {
+	systems        []api.System
+	goods          []api.GoodType
+	shipTypes      []api.ShipType
+	listings       []api.ShipListing
+	loans          []api.AvailableLoan
+	structureTypes []api.StructureType
+	markets        map[string][]*api.MarketGood
+	// wormholes maps a wormhole to the one it leads to
+	wormholes map[string]string
+}
+
+func newWorld() world {
+	w := world{
+		systems: []api.System{
+			{
+				Symbol: "OE",
+				Name:   "Omicron Eridani",
+				Locations: []api.Location{
+					{Symbol: "OE-PM", Type: "PLANET", Name: "Prime", X: 20, Y: -25},
+					{Symbol: "OE-PM-TR", Type: "MOON", Name: "Tritus", X: 21, Y: -26},
+					{Symbol: "OE-CR", Type: "ASTEROID", Name: "Carth", X: 66, Y: 30, AllowsConstruction: true},
+					{Symbol: "OE-W-XV", Type: api.WormholeLocationType, Name: "Wormhole", X: -44, Y: 82},
+				},
+			},
+			{
+				Symbol: "XV",
+				Name:   "Xeno Vala",
+				Locations: []api.Location{
+					{Symbol: "XV-BN", Type: "PLANET", Name: "Bitan", X: -8, Y: 13},
+					{Symbol: "XV-W-OE", Type: api.WormholeLocationType, Name: "Wormhole", X: 37, Y: 42},
+				},
+			},
+		},
+		goods: []api.GoodType{
+			{Symbol: "FUEL", Name: "Fuel", VolumePerUnit: 1},
+			{Symbol: "METALS", Name: "Metals", VolumePerUnit: 1},
+			{Symbol: "FOOD", Name: "Food", VolumePerUnit: 1},
+			{Symbol: "MACHINERY", Name: "Machinery", VolumePerUnit: 4},
+			{Symbol: "CHEMICALS", Name: "Chemicals", VolumePerUnit: 1},
+		},
+		shipTypes: []api.ShipType{
+			{Type: "JW-MK-I", Class: "MK-I", Manufacturer: "Jackshaw", MaxCargo: 50, Speed: 1, Plating: 5, Weapons: 5},
+			{Type: "GR-MK-I", Class: "MK-I", Manufacturer: "Gravager", MaxCargo: 100, Speed: 1, Plating: 10, Weapons: 5},
+			{Type: "EM-MK-II", Class: "MK-II", Manufacturer: "Electrum", MaxCargo: 75, Speed: 2, Plating: 5, Weapons: 10},
+		},
+		loans: []api.AvailableLoan{
+			{Type: "STARTUP", Amount: 200000, Rate: 40, TermInDays: 2, CollateralRequired: false},
+		},
+		structureTypes: []api.StructureType{
+			{Type: "MINE", Name: "Mine", Price: 50000, AllowedLocationTypes: []string{"ASTEROID"}, Consumes: []string{"MACHINERY"}, Produces: []string{"METALS"}},
+			{Type: "CHEMICAL_PLANT", Name: "Chemical Plant", Price: 80000, AllowedLocationTypes: []string{"ASTEROID", "PLANET"}, Consumes: []string{"METALS"}, Produces: []string{"CHEMICALS"}},
+		},
+		wormholes: map[string]string{
+			"OE-W-XV": "XV-W-OE",
+			"XV-W-OE": "OE-W-XV",
+		},
+	}
+
+	w.listings = []api.ShipListing{
+		w.listing("JW-MK-I", api.PurchaseLocation{System: "OE", Location: "OE-PM-TR", Price: 21125}),
+		w.listing("GR-MK-I", api.PurchaseLocation{System: "OE", Location: "OE-PM-TR", Price: 42650}, api.PurchaseLocation{System: "XV", Location: "XV-BN", Price: 44000}),
+		w.listing("EM-MK-II", api.PurchaseLocation{System: "XV", Location: "XV-BN", Price: 98000}),
+	}
+
+	w.markets = map[string][]*api.MarketGood{
+		"OE-PM": {
+			marketGood("FUEL", 1, 3, 1, 100000),
+			marketGood("METALS", 1, 6, 1, 5000),
+			marketGood("MACHINERY", 4, 80, 4, 1000),
+		},
+		"OE-PM-TR": {
+			marketGood("FUEL", 1, 2, 1, 100000),
+			marketGood("FOOD", 1, 4, 1, 8000),
+			marketGood("METALS", 1, 9, 2, 2000),
+		},
+		"OE-CR": {
+			marketGood("FUEL", 1, 4, 1, 20000),
+			marketGood("METALS", 1, 4, 1, 30000),
+		},
+		"XV-BN": {
+			marketGood("FUEL", 1, 3, 1, 50000),
+			marketGood("MACHINERY", 4, 120, 6, 500),
+			marketGood("CHEMICALS", 1, 30, 3, 2000),
+		},
+	}
+
+	return w
+}
+
+func (w world) listing(shipType string, locations ...api.PurchaseLocation) api.ShipListing {
+	t, _ := w.shipType(shipType)
+	return api.ShipListing{
+		Type:              t.Type,
+		Class:             t.Class,
+		Manufacturer:      t.Manufacturer,
+		MaxCargo:          t.MaxCargo,
+		Speed:             t.Speed,
+		Plating:           t.Plating,
+		Weapons:           t.Weapons,
+		PurchaseLocations: locations,
+	}
+}
+
+func marketGood(symbol string, volume int, price int64, spread int64, quantity int) *api.MarketGood {
+	return &api.MarketGood{
+		Symbol:               symbol,
+		VolumePerUnit:        volume,
+		PricePerUnit:         price,
+		PurchasePricePerUnit: price + spread,
+		SellPricePerUnit:     price - spread,
+		Spread:               spread,
+		QuantityAvailable:    quantity,
+	}
+}
+
+func (w world) location(symbol string) (api.Location, bool) {
+	for _, system := range w.systems {
+		for _, location := range system.Locations {
+			if location.Symbol == symbol {
+				return location, true
+			}
+		}
+	}
+	return api.Location{}, false
+}
+
+func (w world) shipType(shipType string) (api.ShipType, bool) {
+	for _, t := range w.shipTypes {
+		if t.Type == shipType {
+			return t, true
+		}
+	}
+	return api.ShipType{}, false
+}
+
+func (w world) structureType(structureType string) (api.StructureType, bool) {
+	for _, t := range w.structureTypes {
+		if t.Type == structureType {
+			return t, true
+		}
+	}
+	return api.StructureType{}, false
+}
+
+func (w world) volume(good string) int {
+	for _, g := range w.goods {
+		if g.Symbol == good {
+			return g.VolumePerUnit
+		}
+	}
+	return 1
+}
+
+// shipPrice returns the lowest price a ship type is sold for.
+func (w world) shipPrice(shipType string) int64 {
+	var price int64
+	for _, listing := range w.listings {
+		if listing.Type != shipType {
+			continue
+		}
+		for _, location := range listing.PurchaseLocations {
+			if price == 0 || location.Price < price {
+				price = location.Price
+			}
+		}
+	}
+	return price
+}
+
+// systemOf returns the system symbol of a location symbol.
+func systemOf(location string) string {
+	return strings.SplitN(location, "-", 2)[0]
+}
+
+func distance(a api.Location, b api.Location) int {
+	dx := float64(a.X - b.X)
+	dy := float64(a.Y - b.Y)
+	return int(math.Ceil(math.Sqrt(dx*dx + dy*dy)))
+}
+
+// newShip creates a ship docked at location, mu must be held.
+func (s *Server) newShip(shipType string, location string) (*api.Ship, error) {
+	t, ok := s.world.shipType(shipType)
+	if !ok {
+		return nil, fmt.Errorf("apitest: no ship type %s", shipType)
+	}
+	loc, ok := s.world.location(location)
+	if !ok {
+		return nil, fmt.Errorf("apitest: no location %s", location)
+	}
+
+	return &api.Ship{
+		ID:             s.newID(),
+		Type:           t.Type,
+		Class:          t.Class,
+		Manufacturer:   t.Manufacturer,
+		Location:       loc.Symbol,
+		X:              loc.X,
+		Y:              loc.Y,
+		Cargo:          []api.Cargo{},
+		SpaceAvailable: t.MaxCargo,
+		Speed:          t.Speed,
+		Plating:        t.Plating,
+		Weapons:        t.Weapons,
+		MaxCargo:       t.MaxCargo,
+	}, nil
+}
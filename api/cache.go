@@ -0,0 +1,224 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs are the endpoints cached by WithCache when no ttls are
+// given. Patterns are relative to the base url, segments starting with ":"
+// match any value.
+//
+// Endpoints of the user, docked ships and flight plans change with every
+// action and aren't cached. Purchase and sell orders are the only calls that
+// change a cached endpoint, they invalidate the marketplace.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"/game/systems":                       24 * time.Hour,
+	"/game/systems/:symbol/locations":     24 * time.Hour,
+	"/game/locations/:symbol":             24 * time.Hour,
+	"/game/locations/:symbol/marketplace": time.Minute,
+	"/game/loans":                         time.Hour,
+	"/game/ships":                         time.Hour,
+	"/systems/:symbol/ship-listings":      time.Hour,
+	"/types/goods":                        24 * time.Hour,
+	"/types/ships":                        24 * time.Hour,
+	"/types/loans":                        24 * time.Hour,
+	"/types/structures":                   24 * time.Hour,
+}
+
+// CacheStore is a persistent tier for cached responses, database.Client
+// implements it.
+type CacheStore interface {
+	FetchCacheEntry(key string) (value []byte, expiresAt time.Time, ok bool)
+	SaveCacheEntry(key string, value []byte, expiresAt time.Time) error
+	DeleteCacheEntries(match func(key string) bool) error
+}
+
+// WithCache caches the responses of GET requests to the endpoints in ttls,
+// DefaultCacheTTLs is used if ttls is nil. Entries are kept in memory and
+// in store when it isn't nil.
+func WithCache(ttls map[string]time.Duration, store CacheStore) Option {
+	return func(c *Client) {
+		if ttls == nil {
+			ttls = DefaultCacheTTLs
+		}
+		c.cache = newResponseCache(ttls, store)
+	}
+}
+
+type cacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// responseCache is shared by every copy of a Client.
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	ttls    map[string]time.Duration
+	store   CacheStore
+}
+
+func newResponseCache(ttls map[string]time.Duration, store CacheStore) *responseCache {
+	return &responseCache{
+		entries: map[string]cacheEntry{},
+		ttls:    ttls,
+		store:   store,
+	}
+}
+
+// ttl returns how long the response to a request to endpoint is cached for,
+// endpoint is the path relative to the base url.
+func (rc *responseCache) ttl(method string, endpoint string) (time.Duration, bool) {
+	if rc == nil || method != http.MethodGet {
+		return 0, false
+	}
+	for pattern, ttl := range rc.ttls {
+		if matchPath(pattern, endpoint) {
+			return ttl, ttl > 0
+		}
+	}
+	return 0, false
+}
+
+// cacheKey is the key of the response to url. Authenticated responses can
+// depend on who asks, like marketplaces that are only visible where the user
+// has a ship, so the username is added to their key as a fragment, which
+// leaves the path matched by invalidate untouched.
+func (c Client) cacheKey(rawURL string, headers Headers) string {
+	if headers["Authorization"] == "" {
+		return rawURL
+	}
+	return rawURL + "#" + url.QueryEscape(c.username)
+}
+
+// get decodes the entry for key into v, it returns false on a miss.
+func (rc *responseCache) get(key string, v interface{}) bool {
+	rc.mu.Lock()
+	entry, ok := rc.entries[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(rc.entries, key)
+		ok = false
+	}
+	rc.mu.Unlock()
+
+	if !ok && rc.store != nil {
+		entry.value, entry.expiresAt, ok = rc.store.FetchCacheEntry(key)
+		ok = ok && time.Now().Before(entry.expiresAt)
+		if ok {
+			rc.mu.Lock()
+			rc.entries[key] = entry
+			rc.mu.Unlock()
+		}
+	}
+
+	// decoded the same way as a live response
+	return ok && json.NewDecoder(bytes.NewReader(entry.value)).Decode(v) == nil
+}
+
+// set caches the raw body of a response for ttl.
+func (rc *responseCache) set(key string, body []byte, ttl time.Duration) error {
+	value := make([]byte, len(body))
+	copy(value, body)
+	entry := cacheEntry{value: value, expiresAt: time.Now().Add(ttl)}
+
+	rc.mu.Lock()
+	rc.entries[key] = entry
+	rc.mu.Unlock()
+
+	if rc.store != nil {
+		return rc.store.SaveCacheEntry(key, entry.value, entry.expiresAt)
+	}
+	return nil
+}
+
+// invalidate drops the entries whose endpoint matches one of patterns.
+func (rc *responseCache) invalidate(baseURL string, patterns ...string) error {
+	if rc == nil {
+		return nil
+	}
+
+	match := func(key string) bool {
+		endpoint := relativePath(baseURL, key)
+		for _, pattern := range patterns {
+			if matchPath(pattern, endpoint) {
+				return true
+			}
+		}
+		return false
+	}
+
+	rc.mu.Lock()
+	for key := range rc.entries {
+		if match(key) {
+			delete(rc.entries, key)
+		}
+	}
+	rc.mu.Unlock()
+
+	if rc.store != nil {
+		return rc.store.DeleteCacheEntries(match)
+	}
+	return nil
+}
+
+// InvalidateCache drops the cached responses of the endpoints matching
+// patterns, which use the same syntax as DefaultCacheTTLs.
+func (c Client) InvalidateCache(patterns ...string) {
+	if err := c.cache.invalidate(c.baseURL, patterns...); err != nil {
+		c.logger.Error("Invalidating the cache failed: ", err)
+	}
+}
+
+// relativePath returns the escaped path of rawURL relative to baseURL.
+func relativePath(baseURL string, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return u.EscapedPath()
+	}
+	return "/" + strings.TrimLeft(strings.TrimPrefix(u.EscapedPath(), base.EscapedPath()), "/")
+}
+
+// matchPath compares a path to a pattern, segments starting with ":" match
+// any single segment.
+func matchPath(pattern string, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if !strings.HasPrefix(segment, ":") && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
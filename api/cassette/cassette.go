@@ -0,0 +1,334 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+// Package cassette provides an http.RoundTripper that records api
+// interactions to disk and replays them, so the api can be used offline.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Mode is whether a Transport records or replays interactions.
+type Mode int
+
+const (
+	// Replay serves recorded responses and never touches the network.
+	Replay Mode = iota
+	// Record sends requests and appends every interaction to the cassette.
+	Record
+)
+
+// Redacted replaces secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction
+// matches a request.
+var ErrNoInteraction = errors.New("cassette: no recorded interaction matches the request")
+
+// Request is the recorded part of a request.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Interaction is a request and the response it got.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the file format of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Transport records or replays interactions from a cassette file.
+type Transport struct {
+	path string
+	mode Mode
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+	// err is the last failure to write the cassette
+	err error
+}
+
+// New creates a Transport for the cassette at path. In replay mode the
+// cassette must exist, in record mode it is created or appended to and next
+// is used to send requests, http.DefaultTransport is used if next is nil.
+func New(path string, mode Mode, next http.RoundTripper) (*Transport, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	t := &Transport{
+		path: path,
+		mode: mode,
+		next: next,
+	}
+
+	f, err := os.ReadFile(path)
+	if err != nil && !(mode == Record && errors.Is(err, os.ErrNotExist)) {
+		return nil, err
+	}
+	if len(f) > 0 {
+		if err := json.Unmarshal(f, &t.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: could not read %s: %w", path, err)
+		}
+	}
+	t.used = make([]bool, len(t.cassette.Interactions))
+
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  redactQuery(req.URL.RawQuery),
+		Body:   redactBody(body),
+	}
+
+	if t.mode == Replay {
+		return t.replay(req, recorded)
+	}
+	return t.record(req, recorded)
+}
+
+func (t *Transport) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// interactions are served in order, the last match is reused once every
+	// match has been served so that polling keeps working
+	match := -1
+	for i, interaction := range t.cassette.Interactions {
+		if !matches(interaction.Request, recorded) {
+			continue
+		}
+		match = i
+		if !t.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		target := recorded.Path
+		if recorded.Query != "" {
+			target += "?" + recorded.Query
+		}
+		return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, target)
+	}
+	t.used[match] = true
+
+	return t.cassette.Interactions[match].Response.toHTTP(req), nil
+}
+
+func (t *Transport) record(req *http.Request, recorded Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(buf))
+
+	// the length changes when the body is redacted
+	header := res.Header.Clone()
+	header.Del("Set-Cookie")
+	header.Del("Content-Length")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cassette.Interactions = append(t.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     header,
+			Body:       redactBody(buf),
+		},
+	})
+	t.used = append(t.used, true)
+
+	// the request reached the server, its response must not be replaced by
+	// a recording failure. The whole cassette is written each time so the
+	// interaction is saved by the next successful write.
+	t.err = t.save()
+	return res, nil
+}
+
+// Err returns the error of the last attempt to write the cassette, it is nil
+// once a write succeeds.
+func (t *Transport) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.err
+}
+
+// save writes the cassette to disk, mu must be held.
+func (t *Transport) save() error {
+	buf, err := json.MarshalIndent(t.cassette, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.path, buf, 0600); err != nil {
+		return fmt.Errorf("cassette: could not write %s: %w", t.path, err)
+	}
+	return nil
+}
+
+func (r Response) toHTTP(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewBufferString(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// readBody reads the body of req and puts back a copy for the next reader.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	buf, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(buf))
+	return buf, nil
+}
+
+// matches compares requests, queries are compared regardless of the order of
+// their parameters and json bodies are compared by value.
+func matches(a Request, b Request) bool {
+	if a.Method != b.Method || a.Path != b.Path {
+		return false
+	}
+	if normalizeQuery(a.Query) != normalizeQuery(b.Query) {
+		return false
+	}
+	return normalize(a.Body) == normalize(b.Body)
+}
+
+func normalizeQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+	return values.Encode()
+}
+
+func normalize(body string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return body
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(buf)
+}
+
+// redactQuery replaces the value of the token parameter of a query.
+func redactQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil || values.Get("token") == "" {
+		return query
+	}
+	values.Set("token", Redacted)
+	return values.Encode()
+}
+
+// redactBody replaces the value of every "token" field of a json body.
+func redactBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	if !redact(v) {
+		return string(body)
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(buf)
+}
+
+// redact walks v and returns true if a token was replaced.
+func redact(v interface{}) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if key == "token" {
+				v[key] = Redacted
+				redacted = true
+			} else if redact(val) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, val := range v {
+			if redact(val) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
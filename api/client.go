@@ -20,9 +20,16 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/yi-fan-song/space-kraken/log"
 )
@@ -31,6 +38,7 @@ const (
 	// OkStatusMessage represents the message that the api will return when it is online
 	OkStatusMessage = "spacetraders is currently online and available to play"
 
+	// BaseUrl is the default url of the api
 	BaseUrl = "https://api.spacetraders.io"
 )
 
@@ -38,19 +46,59 @@ const (
 type Client struct {
 	username string
 	token    string
+	baseURL  string
 
 	httpClient *http.Client
 	logger     log.Logger
+	limiter    *rateLimiter
+	retry      RetryPolicy
+	cache      *responseCache
+	metrics    Metrics
+}
+
+// Option configures a Client created with New
+type Option func(*Client)
+
+// WithBaseURL sets the url every endpoint is relative to, it can point to a
+// mock server, a proxy or another game server.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithRateLimit sets the number of requests per second and the burst size
+// allowed by the client. A rate of 0 disables rate limiting.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(c *Client) {
+		if rate <= 0 {
+			c.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = newRateLimiter(rate, burst)
+	}
 }
 
 // New creates a new api client
-func New(username string, token string, httpClient *http.Client, logger log.Logger) Client {
-	return Client{
+func New(username string, token string, httpClient *http.Client, logger log.Logger, opts ...Option) Client {
+	c := Client{
 		username:   username,
 		token:      token,
+		baseURL:    BaseUrl,
 		httpClient: httpClient,
 		logger:     logger,
+		limiter:    newRateLimiter(DefaultRate, DefaultBurst),
+		retry:      DefaultRetryPolicy,
 	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
 }
 
 // Headers is an alias for a map string->string
@@ -59,12 +107,122 @@ type Headers map[string]string
 // Do does a request and parses the response into v, type of v should
 // correspond to expected response.
 //
+// When the client has a cache, GET requests to cached endpoints are served
+// from it until their ttl runs out.
+//
+// Network errors and retryable statuses are retried following the client's
+// retry policy. Requests that aren't idempotent are only retried when they
+// failed before reaching the server.
+//
 // If the api returns an error, a corresponding error will be returned.
-func (c Client) Do(url string, method string, body io.Reader, headers Headers, v interface{}) (err error) {
+func (c Client) Do(ctx context.Context, url string, method string, body io.Reader, headers Headers, v interface{}) error {
+	return c.do(ctx, relativePath(c.baseURL, url), url, method, body, headers, v)
+}
+
+// do is Do with the endpoint that the request is counted under in the
+// client's metrics, so that requests built from a path template share one
+// label.
+func (c Client) do(ctx context.Context, endpoint string, url string, method string, body io.Reader, headers Headers, v interface{}) (err error) {
+	ttl, cacheable := c.cache.ttl(method, relativePath(c.baseURL, url))
+	key := c.cacheKey(url, headers)
+	if cacheable && c.cache.get(key, v) {
+		c.logger.Infof("Using the cached response for url: %s", url)
+		return
+	}
+
 	c.logger.Infof("Making a %s request to url: %s", method, url)
 
-	var req *http.Request
-	req, err = http.NewRequest(method, url, body)
+	// the body is kept so that rate limited requests can be sent again
+	var payload []byte
+	if body != nil {
+		payload, err = io.ReadAll(body)
+		if err != nil {
+			return
+		}
+	}
+
+	idempotent := isIdempotent(method)
+
+	var res *http.Response
+	rateLimited := 0
+	for attempt := 1; ; attempt++ {
+		var sent bool
+		res, sent, err = c.send(ctx, endpoint, url, method, payload, headers)
+		if err != nil {
+			if ctx.Err() == nil && (idempotent || !sent) && c.retry.canRetry(attempt) {
+				if err = c.waitForRetry(ctx, attempt, err); err != nil {
+					c.observeError(err, "canceled")
+					return
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				c.observeError(err, "canceled")
+			} else {
+				c.observeError(err, "network")
+			}
+			return
+		}
+
+		if res.StatusCode == http.StatusTooManyRequests && rateLimited < maxRateLimitRetries {
+			// the api did not process the request, it is always safe to resend
+			rateLimited++
+			attempt--
+			wait := retryAfter(res.Header)
+			res.Body.Close()
+			c.logger.Infof("Rate limited by the api, retrying in %s", wait)
+			if c.limiter == nil {
+				// without a limiter the next request would go out straight away
+				if err = sleep(ctx, wait); err != nil {
+					c.observeError(err, "canceled")
+					return
+				}
+				continue
+			}
+			c.limiter.block(wait)
+			continue
+		}
+
+		if idempotent && c.retry.retryableStatus(res.StatusCode) && c.retry.canRetry(attempt) {
+			res.Body.Close()
+			if err = c.waitForRetry(ctx, attempt, fmt.Errorf("status %s", res.Status)); err != nil {
+				c.observeError(err, "canceled")
+				return
+			}
+			continue
+		}
+		break
+	}
+
+	// the body is cached as received so that callers decoding the same
+	// endpoint into other types get every field
+	var raw bytes.Buffer
+	var tee io.Writer
+	if cacheable {
+		tee = &raw
+	}
+	err = c.decodeResponse(res, method, v, tee)
+	if err != nil {
+		c.observeError(err, "invalid_response")
+	}
+	if err == nil && cacheable && raw.Len() > 0 {
+		if err := c.cache.set(key, raw.Bytes(), ttl); err != nil {
+			c.logger.Error("Caching the response failed: ", err)
+		}
+	}
+	return
+}
+
+// send waits for the rate limiter and makes a single request. sent is false
+// when the request failed before its headers were written to the server.
+func (c Client) send(ctx context.Context, endpoint string, url string, method string, payload []byte, headers Headers) (res *http.Response, sent bool, err error) {
+	waited, err := c.limiter.wait(ctx)
+	if err != nil {
+		return
+	}
+	c.observeRateLimitWait(waited)
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
 	if err != nil {
 		return
 	}
@@ -72,41 +230,123 @@ func (c Client) Do(url string, method string, body io.Reader, headers Headers, v
 		req.Header.Add(key, val)
 	}
 
-	var res *http.Response
+	trace := &httptrace.ClientTrace{
+		WroteHeaders: func() { sent = true },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
 	res, err = c.httpClient.Do(req)
 	if err != nil {
+		c.observeRequest(method, endpoint, 0, time.Since(start))
 		return
 	}
+	c.observeRequest(method, endpoint, res.StatusCode, time.Since(start))
+	c.limiter.update(res.Header)
+	return
+}
+
+func (c Client) waitForRetry(ctx context.Context, attempt int, cause error) error {
+	wait := c.retry.backoff(attempt)
+	c.logger.Infof("Request failed (%s), retrying in %s", cause, wait)
+	return sleep(ctx, wait)
+}
+
+// sleep pauses for d or until ctx is done, in which case the context's
+// error is returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
 
-	var buf []byte
-	buf, err = io.ReadAll(res.Body)
-	if err != nil {
-		return
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	defer func() {
-		err := res.Body.Close()
-		if err != nil {
-			c.logger.Error("Error occured but may not affect the output, ", err)
+}
+
+// Endpoint describes a route of the api
+type Endpoint struct {
+	Method string
+	// Path is relative to the base url, segments starting with ":" are
+	// replaced by the matching Params
+	Path string
+	// Auth is true if the endpoint needs the user's token
+	Auth bool
+}
+
+// Params are the values of the path parameters of an Endpoint, the
+// username parameter defaults to the client's username
+type Params map[string]string
+
+// Request does a request to endpoint and parses the response into v.
+//
+// Path parameters are escaped, query is appended to the url when not empty
+// and body is encoded as json when not nil.
+func (c Client) Request(ctx context.Context, endpoint Endpoint, params Params, query url.Values, body interface{}, v interface{}) error {
+	headers := Headers{}
+	if endpoint.Auth {
+		if err := c.checkAuth(); err != nil {
+			return err
 		}
-	}()
+		headers["Authorization"] = "Bearer " + c.token
+	}
 
-	var apiErr ResponseError
-	err = json.Unmarshal(buf, &apiErr)
+	path, err := c.expandPath(endpoint.Path, params)
 	if err != nil {
-		return
+		return err
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
 	}
 
-	if apiErr.Error.Code != 0 {
-		err = apiErr.ToClientError()
-		return
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(buf)
+		headers["Content-Type"] = "application/json"
 	}
 
-	err = json.Unmarshal(buf, v)
-	return
+	return c.do(ctx, endpoint.Path, u, endpoint.Method, reader, headers, v)
 }
 
-func createAuthHeader(token string) Headers {
-	return Headers{"Authorization": "Bearer " + token}
+// expandPath replaces the parameters of a path template with their escaped
+// values.
+func (c Client) expandPath(template string, params Params) (string, error) {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		name := segment[1:]
+		value, ok := params[name]
+		if !ok && name == "username" {
+			value, ok = c.username, true
+		}
+		if !ok || value == "" {
+			return "", fmt.Errorf("missing value for parameter %s of %s", name, template)
+		}
+		segments[i] = url.PathEscape(value)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// checkAuth returns an error if the client does not have auth set.
+func (c Client) checkAuth() error {
+	if !c.HasAuth() {
+		return fmt.Errorf("Client without auth only supports account creation and status")
+	}
+	return nil
 }
 
 // HasAuth returns true if username and token is set
@@ -19,8 +19,35 @@
 
 package api
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
 
+// Game error codes returned in the body of failed requests
+const (
+	CodeUnauthorized      = 40101
+	CodeRateLimited       = 42901
+	CodeInsufficientFunds = 2004
+	CodeCargoFull         = 2007
+
+	// CodeNotFound isn't a game code, the api reuses the http status as the
+	// code of missing resources. ErrNotFound also matches any 404 response
+	// through its status.
+	CodeNotFound = 404
+)
+
+// Sentinel errors matched by APIError with errors.Is
+var (
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrNotFound          = errors.New("not found")
+	ErrCargoFull         = errors.New("cargo full")
+)
+
+// ResponseError is the body the api returns when a request fails
 type ResponseError struct {
 	Error struct {
 		Message string `json:"message"`
@@ -28,16 +55,61 @@ type ResponseError struct {
 	} `json:"error"`
 }
 
-func (err ResponseError) ToClientError() ClientError {
-	return ClientError{
-		Name: fmt.Sprint(err.Error.Code) + ": " + err.Error.Message,
+// ToAPIError creates an APIError from the response body along with the
+// status and endpoint of the request.
+func (err ResponseError) ToAPIError(statusCode int, method string, endpoint string) *APIError {
+	return &APIError{
+		StatusCode: statusCode,
+		Code:       err.Error.Code,
+		Message:    err.Error.Message,
+		Method:     method,
+		Endpoint:   endpoint,
 	}
 }
 
-type ClientError struct {
-	Name string
+// APIError is returned when the api answers a request with an error
+type APIError struct {
+	// StatusCode is the http status of the response
+	StatusCode int
+	// Code is the game error code, it is 0 if the body had none
+	Code    int
+	Message string
+	// Method and Endpoint identify the request, Endpoint is the url path
+	Method   string
+	Endpoint string
+	// Body is the start of the response body when it wasn't a game error
+	Body string
 }
 
-func (err ClientError) Error() string {
-	return err.Name
+func (err *APIError) Error() string {
+	message := err.Message
+	if message == "" {
+		message = http.StatusText(err.StatusCode)
+	}
+	s := fmt.Sprintf("%s %s: %s (status %d", err.Method, err.Endpoint, message, err.StatusCode)
+	if err.Code != 0 {
+		s += fmt.Sprintf(", code %d", err.Code)
+	}
+	s += ")"
+	if err.Body != "" {
+		s += fmt.Sprintf(": %q", err.Body)
+	}
+	return s
+}
+
+// Is reports whether err corresponds to one of the sentinel errors.
+func (err *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return err.Code == CodeUnauthorized || err.StatusCode == http.StatusUnauthorized
+	case ErrRateLimited:
+		return err.Code == CodeRateLimited || err.StatusCode == http.StatusTooManyRequests
+	case ErrNotFound:
+		return err.Code == CodeNotFound || err.StatusCode == http.StatusNotFound
+	case ErrInsufficientFunds:
+		return err.Code == CodeInsufficientFunds
+	case ErrCargoFull:
+		return err.Code == CodeCargoFull
+	}
+	return false
 }
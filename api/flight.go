@@ -0,0 +1,125 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// FlightPlan is the model for a flight plan of a ship owned by the user
+type FlightPlan struct {
+	ID                     string     `json:"id"`
+	ShipID                 string     `json:"shipId"`
+	Departure              string     `json:"departure"`
+	Destination            string     `json:"destination"`
+	Distance               int        `json:"distance"`
+	FuelConsumed           int        `json:"fuelConsumed"`
+	FuelRemaining          int        `json:"fuelRemaining"`
+	CreatedAt              time.Time  `json:"createdAt"`
+	ArrivesAt              time.Time  `json:"arrivesAt"`
+	TerminatedAt           *time.Time `json:"terminatedAt"`
+	TimeRemainingInSeconds int        `json:"timeRemainingInSeconds"`
+}
+
+// SystemFlightPlan is the model for a flight plan active in a system, it
+// can belong to any user
+type SystemFlightPlan struct {
+	ID          string    `json:"id"`
+	ShipID      string    `json:"shipId"`
+	ShipType    string    `json:"shipType"`
+	Username    string    `json:"username"`
+	Departure   string    `json:"departure"`
+	Destination string    `json:"destination"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ArrivesAt   time.Time `json:"arrivesAt"`
+}
+
+// FetchedFlightPlan is the response model for creating or fetching a flight plan
+type FetchedFlightPlan struct {
+	FlightPlan FlightPlan `json:"flightPlan"`
+}
+
+// SystemFlightPlans is the response model for /game/systems/:symbol/flight-plans
+type SystemFlightPlans struct {
+	FlightPlans []SystemFlightPlan `json:"flightPlans"`
+}
+
+// CreateFlightPlan sends the ship with id shipID to destination
+func (c Client) CreateFlightPlan(ctx context.Context, shipID string, destination string) (plan FlightPlan, err error) {
+	c.logger.Infof("Creating a flight plan for ship %s to %s...", shipID, destination)
+
+	body := struct {
+		ShipID      string `json:"shipId"`
+		Destination string `json:"destination"`
+	}{shipID, destination}
+
+	var res FetchedFlightPlan
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/flight-plans", true}, nil, nil, body, &res)
+	if err != nil {
+		c.logger.Error("Creating flight plan failed: ", err)
+		return
+	}
+	return res.FlightPlan, err
+}
+
+// FetchFlightPlan fetches the flight plan with id planID
+func (c Client) FetchFlightPlan(ctx context.Context, planID string) (plan FlightPlan, err error) {
+	c.logger.Infof("Fetching flight plan %s...", planID)
+
+	var res FetchedFlightPlan
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/users/:username/flight-plans/:planId", true}, Params{"planId": planID}, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching flight plan failed: ", err)
+		return
+	}
+	return res.FlightPlan, err
+}
+
+// FetchSystemFlightPlans fetches the flight plans active in system
+func (c Client) FetchSystemFlightPlans(ctx context.Context, system string) (plans []SystemFlightPlan, err error) {
+	c.logger.Infof("Fetching the flight plans in system %s...", system)
+
+	var res SystemFlightPlans
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/systems/:symbol/flight-plans", true}, Params{"symbol": system}, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching system flight plans failed: ", err)
+		return
+	}
+	return res.FlightPlans, err
+}
+
+// WarpJump sends the ship with id shipID through the wormhole it is docked at
+func (c Client) WarpJump(ctx context.Context, shipID string) (plan FlightPlan, err error) {
+	c.logger.Infof("Attempting a warp jump with ship %s...", shipID)
+
+	body := struct {
+		ShipID string `json:"shipId"`
+	}{shipID}
+
+	var res FetchedFlightPlan
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/warp-jump", true}, nil, nil, body, &res)
+	if err != nil {
+		c.logger.Error("Warp jump failed: ", err)
+		return
+	}
+	return res.FlightPlan, err
+}
@@ -0,0 +1,50 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// NetWorthRank is the model for a user's position on the leaderboard
+type NetWorthRank struct {
+	Username string `json:"username"`
+	NetWorth int64  `json:"netWorth"`
+	Rank     int    `json:"rank"`
+}
+
+// Leaderboard is the response model for /game/leaderboard/net-worth
+type Leaderboard struct {
+	NetWorth     []NetWorthRank `json:"netWorth"`
+	UserNetWorth NetWorthRank   `json:"userNetWorth"`
+}
+
+// FetchLeaderboard fetches the net worth leaderboard along with the rank
+// of the user
+func (c Client) FetchLeaderboard(ctx context.Context) (leaderboard Leaderboard, err error) {
+	c.logger.Info("Fetching the net worth leaderboard...")
+
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/leaderboard/net-worth", true}, nil, nil, nil, &leaderboard)
+	if err != nil {
+		c.logger.Error("Fetching leaderboard failed: ", err)
+	}
+	return
+}
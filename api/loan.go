@@ -0,0 +1,114 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Loan is the model for a loan taken by a user
+type Loan struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	Status          string    `json:"status"`
+	Due             time.Time `json:"due"`
+	RepaymentAmount int64     `json:"repaymentAmount"`
+}
+
+// AvailableLoan is the model for a loan that can be taken
+type AvailableLoan struct {
+	Type               string `json:"type"`
+	Amount             int64  `json:"amount"`
+	Rate               int    `json:"rate"`
+	TermInDays         int    `json:"termInDays"`
+	CollateralRequired bool   `json:"collateralRequired"`
+}
+
+// AvailableLoans is the response model for /game/loans
+type AvailableLoans struct {
+	Loans []AvailableLoan `json:"loans"`
+}
+
+// Loans is the response model for /users/:username/loans
+type Loans struct {
+	Loans []Loan `json:"loans"`
+}
+
+// TakenLoan is the response model for taking out a loan
+type TakenLoan struct {
+	Credits int64 `json:"credits"`
+	Loan    Loan  `json:"loan"`
+}
+
+// FetchAvailableLoans fetches the loans that can be taken
+func (c Client) FetchAvailableLoans(ctx context.Context) (loans []AvailableLoan, err error) {
+	c.logger.Info("Fetching available loans...")
+
+	var res AvailableLoans
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/loans", true}, nil, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching available loans failed: ", err)
+		return
+	}
+	return res.Loans, err
+}
+
+// FetchLoans fetches the loans taken by the user
+func (c Client) FetchLoans(ctx context.Context) (loans []Loan, err error) {
+	c.logger.Infof("Fetching the loans of %s...", c.username)
+
+	var res Loans
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/users/:username/loans", true}, nil, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching loans failed: ", err)
+		return
+	}
+	return res.Loans, err
+}
+
+// TakeLoan takes out a loan of type loanType
+func (c Client) TakeLoan(ctx context.Context, loanType string) (loan TakenLoan, err error) {
+	c.logger.Infof("Taking a loan of type %s...", loanType)
+
+	body := struct {
+		Type string `json:"type"`
+	}{loanType}
+
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/loans", true}, nil, nil, body, &loan)
+	if err != nil {
+		c.logger.Error("Taking loan failed: ", err)
+	}
+	return
+}
+
+// PayLoan pays off the loan with id loanID
+func (c Client) PayLoan(ctx context.Context, loanID string) (user FetchedUser, err error) {
+	c.logger.Infof("Paying off loan %s...", loanID)
+
+	err = c.Request(ctx, Endpoint{http.MethodPut, "/users/:username/loans/:loanId", true}, Params{"loanId": loanID}, nil, nil, &user)
+	if err != nil {
+		c.logger.Error("Paying loan failed: ", err)
+		return
+	}
+	c.observeAccount(user.User)
+	return
+}
@@ -0,0 +1,54 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// MarketGood is the model for a good traded at a location's marketplace
+type MarketGood struct {
+	Symbol               string `json:"symbol"`
+	VolumePerUnit        int    `json:"volumePerUnit"`
+	PricePerUnit         int64  `json:"pricePerUnit"`
+	PurchasePricePerUnit int64  `json:"purchasePricePerUnit"`
+	SellPricePerUnit     int64  `json:"sellPricePerUnit"`
+	Spread               int64  `json:"spread"`
+	QuantityAvailable    int    `json:"quantityAvailable"`
+}
+
+// Marketplace is the response model for /game/locations/:symbol/marketplace
+type Marketplace struct {
+	Marketplace []MarketGood `json:"marketplace"`
+}
+
+// FetchMarketplace fetches the goods traded at location
+func (c Client) FetchMarketplace(ctx context.Context, location string) (goods []MarketGood, err error) {
+	c.logger.Infof("Fetching the marketplace of %s...", location)
+
+	var res Marketplace
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/locations/:symbol/marketplace", true}, Params{"symbol": location}, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching marketplace failed: ", err)
+		return
+	}
+	return res.Marketplace, err
+}
@@ -0,0 +1,87 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Metrics receives measurements about the api usage of a Client
+type Metrics interface {
+	// ObserveRequest is called for every request sent to the api, status is 0
+	// when no response was received.
+	ObserveRequest(method string, endpoint string, status int, duration time.Duration)
+	// ObserveError is called for every failed call with the game error code,
+	// the http status when there is no game code, or the kind of failure.
+	ObserveError(code string)
+	// ObserveRateLimitWait is called whenever the rate limiter delayed a request.
+	ObserveRateLimitWait(d time.Duration)
+	// ObserveAccount is called whenever the account of the user is fetched.
+	ObserveAccount(username string, credits int64, ships int)
+}
+
+// WithMetrics sets where the client reports its api usage.
+func WithMetrics(m Metrics) Option {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+func (c Client) observeRequest(method string, endpoint string, status int, duration time.Duration) {
+	if c.metrics != nil {
+		c.metrics.ObserveRequest(method, endpoint, status, duration)
+	}
+}
+
+func (c Client) observeError(err error, fallback string) {
+	if c.metrics != nil {
+		c.metrics.ObserveError(errorCode(err, fallback))
+	}
+}
+
+func (c Client) observeRateLimitWait(d time.Duration) {
+	if c.metrics != nil && d > 0 {
+		c.metrics.ObserveRateLimitWait(d)
+	}
+}
+
+func (c Client) observeAccount(user InnerUser) {
+	if c.metrics != nil {
+		c.metrics.ObserveAccount(user.Username, user.Credits, len(user.Ships))
+	}
+}
+
+// errorCode gives the label an error is counted under, fallback is used for
+// errors that don't come from the api.
+func errorCode(err error, fallback string) string {
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.Code != 0:
+		return strconv.Itoa(apiErr.Code)
+	case errors.As(err, &apiErr):
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, ErrResponseTooLarge):
+		return "too_large"
+	default:
+		return fallback
+	}
+}
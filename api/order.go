@@ -0,0 +1,76 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// Order is the model for the details of a purchase or sell order
+type Order struct {
+	Good         string `json:"good"`
+	Quantity     int    `json:"quantity"`
+	PricePerUnit int64  `json:"pricePerUnit"`
+	Total        int64  `json:"total"`
+}
+
+// PlacedOrder is the response model for purchase and sell orders
+type PlacedOrder struct {
+	Credits int64 `json:"credits"`
+	Order   Order `json:"order"`
+	Ship    Ship  `json:"ship"`
+}
+
+// PlacePurchaseOrder buys quantity of good into the cargo of the ship with id shipID
+func (c Client) PlacePurchaseOrder(ctx context.Context, shipID string, good string, quantity int) (order PlacedOrder, err error) {
+	c.logger.Infof("Buying %d %s for ship %s...", quantity, good, shipID)
+
+	order, err = c.placeOrder(ctx, "/users/:username/purchase-orders", shipID, good, quantity)
+	if err != nil {
+		c.logger.Error("Placing purchase order failed: ", err)
+	}
+	return
+}
+
+// PlaceSellOrder sells quantity of good from the cargo of the ship with id shipID
+func (c Client) PlaceSellOrder(ctx context.Context, shipID string, good string, quantity int) (order PlacedOrder, err error) {
+	c.logger.Infof("Selling %d %s from ship %s...", quantity, good, shipID)
+
+	order, err = c.placeOrder(ctx, "/users/:username/sell-orders", shipID, good, quantity)
+	if err != nil {
+		c.logger.Error("Placing sell order failed: ", err)
+	}
+	return
+}
+
+func (c Client) placeOrder(ctx context.Context, path string, shipID string, good string, quantity int) (order PlacedOrder, err error) {
+	body := struct {
+		ShipID   string `json:"shipId"`
+		Good     string `json:"good"`
+		Quantity int    `json:"quantity"`
+	}{shipID, good, quantity}
+
+	err = c.Request(ctx, Endpoint{http.MethodPost, path, true}, nil, nil, body, &order)
+	if err == nil {
+		c.InvalidateCache("/game/locations/:symbol/marketplace")
+	}
+	return
+}
@@ -0,0 +1,207 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRate is the number of requests per second the api allows
+	DefaultRate = 2
+	// DefaultBurst is the number of requests the api allows in a burst
+	DefaultBurst = 10
+
+	// maxRateLimitRetries is how many times a rate limited request is retried
+	maxRateLimitRetries = 3
+	// defaultRetryAfter is used when a 429 response doesn't say how long to wait
+	defaultRetryAfter = time.Second
+)
+
+// RateLimitBudget is the state of the client's rate limiter along with the
+// last budget reported by the api
+type RateLimitBudget struct {
+	// Tokens is the number of requests that can be made right away
+	Tokens float64
+	// Limit, Remaining and Reset are read from the last response headers,
+	// they are zero until a response carried them
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// BlockedUntil is set when the api asked the client to back off
+	BlockedUntil time.Time
+}
+
+// rateLimiter is a token bucket shared by every copy of a Client.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	limit        int
+	remaining    int
+	reset        time.Time
+	blockedUntil time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill adds the tokens accumulated since the last call, mu must be held.
+func (l *rateLimiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// wait blocks until a request can be made or ctx is done, it returns how
+// long it waited.
+func (l *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+
+	var delay time.Duration
+	if l.blockedUntil.After(now) {
+		delay = l.blockedUntil.Sub(now)
+	}
+	// take the token now, a negative balance is paid back by waiting
+	l.tokens--
+	if l.tokens < 0 {
+		if d := time.Duration(-l.tokens / l.rate * float64(time.Second)); d > delay {
+			delay = d
+		}
+	}
+	l.mu.Unlock()
+
+	if err := sleep(ctx, delay); err != nil {
+		// the request won't be made, give the token back
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return delay, err
+	}
+	return delay, nil
+}
+
+// block stops requests from being made for d and empties the bucket.
+func (l *rateLimiter) block(d time.Duration) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := time.Now().Add(d); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+	l.tokens = 0
+}
+
+// update reads the rate limit headers of a response.
+func (l *rateLimiter) update(header http.Header) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit-Second")); err == nil {
+		l.limit = limit
+	}
+	if remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining-Second")); err == nil {
+		l.remaining = remaining
+		// trust the server over our own count when it has less budget left
+		if float64(remaining) < l.tokens {
+			l.tokens = float64(remaining)
+		}
+	}
+	if reset, err := time.Parse(time.RFC3339, header.Get("X-RateLimit-Reset")); err == nil {
+		l.reset = reset
+	}
+}
+
+func (l *rateLimiter) budget() RateLimitBudget {
+	if l == nil {
+		return RateLimitBudget{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	tokens := l.tokens
+	if tokens < 0 {
+		tokens = 0
+	}
+	return RateLimitBudget{
+		Tokens:       tokens,
+		Limit:        l.limit,
+		Remaining:    l.remaining,
+		Reset:        l.reset,
+		BlockedUntil: l.blockedUntil,
+	}
+}
+
+// retryAfter returns how long a rate limited response asks to wait for.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+		return 0
+	}
+	if reset, err := time.Parse(time.RFC3339, header.Get("X-RateLimit-Reset")); err == nil {
+		if d := time.Until(reset); d > 0 {
+			return d
+		}
+	}
+	return defaultRetryAfter
+}
+
+// RateLimit returns the current request budget of the client.
+func (c Client) RateLimit() RateLimitBudget {
+	return c.limiter.budget()
+}
@@ -0,0 +1,156 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxResponseSize is the largest response body that will be decoded
+	maxResponseSize = 10 << 20
+	// maxErrorBodySize is how much of an error response is read
+	maxErrorBodySize = 64 << 10
+	// maxSnippetSize is how much of a non json body is kept in errors
+	maxSnippetSize = 512
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the size
+// the client is willing to read
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// decodeResponse checks the status of res and decodes its body into v. The
+// body is always closed. When raw isn't nil, the json body of a successful
+// response is copied into it as it is decoded.
+func (c Client) decodeResponse(res *http.Response, method string, v interface{}, raw io.Writer) (err error) {
+	defer func() {
+		// drain what is left so the connection can be reused
+		io.Copy(io.Discard, io.LimitReader(res.Body, maxErrorBodySize))
+		if err := res.Body.Close(); err != nil {
+			c.logger.Error("Error occured but may not affect the output, ", err)
+		}
+	}()
+
+	// the escaped path keeps parameters containing slashes in one segment
+	endpoint := res.Request.URL.EscapedPath()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		buf, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		if err != nil {
+			return err
+		}
+
+		var apiErr ResponseError
+		if isJSON(res.Header) && json.Unmarshal(buf, &apiErr) == nil && (apiErr.Error.Code != 0 || apiErr.Error.Message != "") {
+			return apiErr.ToAPIError(res.StatusCode, method, endpoint)
+		}
+		return &APIError{
+			StatusCode: res.StatusCode,
+			Method:     method,
+			Endpoint:   endpoint,
+			Body:       snippet(buf),
+		}
+	}
+
+	if !isJSON(res.Header) {
+		buf, err := io.ReadAll(io.LimitReader(res.Body, maxSnippetSize))
+		if err != nil {
+			return err
+		}
+		if len(buf) == 0 && v == nil {
+			return nil
+		}
+		return &APIError{
+			StatusCode: res.StatusCode,
+			Message:    "unexpected " + res.Header.Get("Content-Type") + " response",
+			Method:     method,
+			Endpoint:   endpoint,
+			Body:       snippet(buf),
+		}
+	}
+
+	var body io.Reader = &cappedReader{r: res.Body, n: maxResponseSize}
+	if raw != nil {
+		body = io.TeeReader(body, raw)
+	}
+	err = json.NewDecoder(body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		if res.StatusCode == http.StatusNoContent || v == nil {
+			return nil
+		}
+		return &APIError{
+			StatusCode: res.StatusCode,
+			Message:    "empty response body",
+			Method:     method,
+			Endpoint:   endpoint,
+		}
+	}
+	return err
+}
+
+// isJSON returns true if the content type of a response is json. A missing
+// content type is assumed to be json.
+func isJSON(header http.Header) bool {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// snippet returns the start of a body for diagnostics.
+func snippet(buf []byte) string {
+	if len(buf) > maxSnippetSize {
+		buf = buf[:maxSnippetSize]
+		// don't cut a rune in half
+		for len(buf) > 0 && !utf8.Valid(buf) {
+			buf = buf[:len(buf)-1]
+		}
+	}
+	return strings.TrimSpace(string(buf))
+}
+
+// cappedReader returns ErrResponseTooLarge once more than n bytes are read.
+type cappedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.n <= 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > c.n {
+		p = p[:c.n]
+	}
+	n, err := c.r.Read(p)
+	c.n -= int64(n)
+	return n, err
+}
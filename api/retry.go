@@ -0,0 +1,104 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it doubles on every
+	// attempt up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// RetryableStatuses are the response codes that are worth retrying
+	RetryableStatuses []int
+}
+
+// DefaultRetryPolicy is the retry policy used by clients created with New
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	RetryableStatuses: []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// WithRetryPolicy sets the retry policy of the client.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		c.retry = policy
+	}
+}
+
+// canRetry returns true if another attempt can be made after attempt.
+func (p RetryPolicy) canRetry(attempt int) bool {
+	return attempt < p.MaxAttempts
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, status := range p.RetryableStatuses {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns how long to wait before the retry following attempt. The
+// delay is picked at random between half and all of the exponential delay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// isIdempotent returns true if sending a request with method more than once
+// has the same effect as sending it once. PUT and DELETE are left out on
+// purpose since the api uses them to pay loans and scrap ships.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
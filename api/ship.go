@@ -0,0 +1,182 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Cargo is an entry of goods held by a ship
+type Cargo struct {
+	Good        string `json:"good"`
+	Quantity    int    `json:"quantity"`
+	TotalVolume int    `json:"totalVolume"`
+}
+
+// Ship is the model for a ship owned by a user
+type Ship struct {
+	ID             string  `json:"id"`
+	Type           string  `json:"type"`
+	Class          string  `json:"class"`
+	Manufacturer   string  `json:"manufacturer"`
+	Location       string  `json:"location,omitempty"`
+	X              int     `json:"x"`
+	Y              int     `json:"y"`
+	Cargo          []Cargo `json:"cargo"`
+	SpaceAvailable int     `json:"spaceAvailable"`
+	Speed          int     `json:"speed"`
+	Plating        int     `json:"plating"`
+	Weapons        int     `json:"weapons"`
+	MaxCargo       int     `json:"maxCargo"`
+}
+
+// PurchaseLocation is a location where a ship listing can be bought
+type PurchaseLocation struct {
+	System   string `json:"system"`
+	Location string `json:"location"`
+	Price    int64  `json:"price"`
+}
+
+// ShipListing is the model for a ship that is for sale
+type ShipListing struct {
+	Type              string             `json:"type"`
+	Class             string             `json:"class"`
+	Manufacturer      string             `json:"manufacturer"`
+	MaxCargo          int                `json:"maxCargo"`
+	Speed             int                `json:"speed"`
+	Plating           int                `json:"plating"`
+	Weapons           int                `json:"weapons"`
+	PurchaseLocations []PurchaseLocation `json:"purchaseLocations"`
+}
+
+// ShipListings is the response model for ship listings
+type ShipListings struct {
+	ShipListings []ShipListing `json:"shipListings"`
+}
+
+// PurchasedShip is the response model for buying a ship
+type PurchasedShip struct {
+	Credits int64 `json:"credits"`
+	Ship    Ship  `json:"ship"`
+}
+
+// FetchShipListings fetches the ships for sale, class and system are
+// optional filters and are ignored when empty.
+func (c Client) FetchShipListings(ctx context.Context, class string, system string) (listings []ShipListing, err error) {
+	c.logger.Info("Fetching ship listings...")
+
+	endpoint := Endpoint{http.MethodGet, "/game/ships", true}
+	if system != "" {
+		endpoint.Path = "/systems/:symbol/ship-listings"
+	}
+	query := url.Values{}
+	if class != "" {
+		query.Set("class", class)
+	}
+
+	var res ShipListings
+	err = c.Request(ctx, endpoint, Params{"symbol": system}, query, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching ship listings failed: ", err)
+		return
+	}
+	return res.ShipListings, err
+}
+
+// BuyShip buys a ship of type shipType at location
+func (c Client) BuyShip(ctx context.Context, location string, shipType string) (ship PurchasedShip, err error) {
+	c.logger.Infof("Buying a ship of type %s at %s...", shipType, location)
+
+	body := struct {
+		Location string `json:"location"`
+		Type     string `json:"type"`
+	}{location, shipType}
+
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/ships", true}, nil, nil, body, &ship)
+	if err != nil {
+		c.logger.Error("Buying ship failed: ", err)
+	}
+	return
+}
+
+// JettisonedCargo is the response model for jettisoning cargo
+type JettisonedCargo struct {
+	ShipID            string `json:"shipId"`
+	Good              string `json:"good"`
+	QuantityRemaining int    `json:"quantityRemaining"`
+}
+
+// TransferedCargo is the response model for transfering cargo between ships
+type TransferedCargo struct {
+	FromShip Ship `json:"fromShip"`
+	ToShip   Ship `json:"toShip"`
+}
+
+// ScrappedShip is the response model for scrapping a ship
+type ScrappedShip struct {
+	Success string `json:"success"`
+}
+
+// JettisonCargo dumps quantity of good from the ship with id shipID
+func (c Client) JettisonCargo(ctx context.Context, shipID string, good string, quantity int) (jettisoned JettisonedCargo, err error) {
+	c.logger.Infof("Jettisoning %d %s from ship %s...", quantity, good, shipID)
+
+	body := struct {
+		Good     string `json:"good"`
+		Quantity int    `json:"quantity"`
+	}{good, quantity}
+
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/ships/:shipId/jettison", true}, Params{"shipId": shipID}, nil, body, &jettisoned)
+	if err != nil {
+		c.logger.Error("Jettisoning cargo failed: ", err)
+	}
+	return
+}
+
+// TransferCargo moves quantity of good from the ship with id fromShipID to
+// the ship with id toShipID, both ships must be at the same location
+func (c Client) TransferCargo(ctx context.Context, fromShipID string, toShipID string, good string, quantity int) (transfered TransferedCargo, err error) {
+	c.logger.Infof("Transfering %d %s from ship %s to ship %s...", quantity, good, fromShipID, toShipID)
+
+	body := struct {
+		ToShipID string `json:"toShipId"`
+		Good     string `json:"good"`
+		Quantity int    `json:"quantity"`
+	}{toShipID, good, quantity}
+
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/ships/:shipId/transfer", true}, Params{"shipId": fromShipID}, nil, body, &transfered)
+	if err != nil {
+		c.logger.Error("Transfering cargo failed: ", err)
+	}
+	return
+}
+
+// ScrapShip scraps the ship with id shipID for credits
+func (c Client) ScrapShip(ctx context.Context, shipID string) (scrapped ScrappedShip, err error) {
+	c.logger.Infof("Scrapping ship %s...", shipID)
+
+	err = c.Request(ctx, Endpoint{http.MethodDelete, "/users/:username/ships/:shipId", true}, Params{"shipId": shipID}, nil, nil, &scrapped)
+	if err != nil {
+		c.logger.Error("Scrapping ship failed: ", err)
+	}
+	return
+}
@@ -20,8 +20,7 @@
 package api
 
 import (
-	"encoding/json"
-	"io"
+	"context"
 	"net/http"
 )
 
@@ -31,37 +30,10 @@ type GameStatus struct {
 }
 
 // FetchStatus gets the status of the api
-func (c Client) FetchStatus() (status GameStatus, err error) {
+func (c Client) FetchStatus(ctx context.Context) (status GameStatus, err error) {
 	c.logger.Info("Fetching game Status...")
 
-	url := "https://api.spacetraders.io/game/status"
-
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		c.logger.Error("Fetching failed: ", err)
-		return
-	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		c.logger.Error("Fetching failed: ", err)
-		return
-	}
-
-	buf := make([]byte, 5000)
-	n, err := res.Body.Read(buf)
-	if err != nil && err != io.EOF {
-		c.logger.Error("Fetching failed: ", err)
-		return
-	}
-	defer func() {
-		err := res.Body.Close()
-		if err != nil {
-			c.logger.Error("Error occured but may not affect the output, ", err)
-		}
-	}()
-
-	err = json.Unmarshal(buf[:n], &status)
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/status", false}, nil, nil, nil, &status)
 	if err != nil {
 		c.logger.Error("Fetching failed: ", err)
 		return
@@ -0,0 +1,133 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// Goods is an amount of a good moved or held by a structure
+type Goods struct {
+	Good     string `json:"good"`
+	Quantity int    `json:"quantity"`
+}
+
+// Structure is the model for a structure owned by the user
+type Structure struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Location  string   `json:"location"`
+	Active    bool     `json:"active"`
+	Status    string   `json:"status"`
+	Inventory []Goods  `json:"inventory"`
+	Consumes  []string `json:"consumes"`
+	Produces  []string `json:"produces"`
+}
+
+// FetchedStructure is the response model for creating a structure
+type FetchedStructure struct {
+	Structure Structure `json:"structure"`
+}
+
+// Structures is the response model for /users/:username/structures
+type Structures struct {
+	Structures []Structure `json:"structures"`
+}
+
+// StructureDeposit is the response model for depositing goods into a structure
+type StructureDeposit struct {
+	Deposit   Goods     `json:"deposit"`
+	Ship      Ship      `json:"ship"`
+	Structure Structure `json:"structure"`
+}
+
+// StructureTransfer is the response model for transfering goods from a
+// structure to a ship
+type StructureTransfer struct {
+	Transfer  Goods     `json:"transfer"`
+	Ship      Ship      `json:"ship"`
+	Structure Structure `json:"structure"`
+}
+
+// CreateStructure builds a structure of type structureType at location
+func (c Client) CreateStructure(ctx context.Context, location string, structureType string) (structure Structure, err error) {
+	c.logger.Infof("Building a structure of type %s at %s...", structureType, location)
+
+	body := struct {
+		Location string `json:"location"`
+		Type     string `json:"type"`
+	}{location, structureType}
+
+	var res FetchedStructure
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/structures", true}, nil, nil, body, &res)
+	if err != nil {
+		c.logger.Error("Building structure failed: ", err)
+		return
+	}
+	return res.Structure, err
+}
+
+// FetchStructures fetches the structures owned by the user
+func (c Client) FetchStructures(ctx context.Context) (structures []Structure, err error) {
+	c.logger.Infof("Fetching the structures of %s...", c.username)
+
+	var res Structures
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/users/:username/structures", true}, nil, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching structures failed: ", err)
+		return
+	}
+	return res.Structures, err
+}
+
+// DepositGoods moves quantity of good from the ship with id shipID into
+// the structure with id structureID
+func (c Client) DepositGoods(ctx context.Context, structureID string, shipID string, good string, quantity int) (deposit StructureDeposit, err error) {
+	c.logger.Infof("Depositing %d %s from ship %s into structure %s...", quantity, good, shipID, structureID)
+
+	err = c.moveStructureGoods(ctx, "/users/:username/structures/:structureId/deposit", structureID, shipID, good, quantity, &deposit)
+	if err != nil {
+		c.logger.Error("Depositing goods failed: ", err)
+	}
+	return
+}
+
+// TransferGoods moves quantity of good from the structure with id
+// structureID into the ship with id shipID
+func (c Client) TransferGoods(ctx context.Context, structureID string, shipID string, good string, quantity int) (transfer StructureTransfer, err error) {
+	c.logger.Infof("Transfering %d %s from structure %s to ship %s...", quantity, good, structureID, shipID)
+
+	err = c.moveStructureGoods(ctx, "/users/:username/structures/:structureId/transfer", structureID, shipID, good, quantity, &transfer)
+	if err != nil {
+		c.logger.Error("Transfering goods failed: ", err)
+	}
+	return
+}
+
+func (c Client) moveStructureGoods(ctx context.Context, path string, structureID string, shipID string, good string, quantity int, v interface{}) error {
+	body := struct {
+		ShipID   string `json:"shipId"`
+		Good     string `json:"good"`
+		Quantity int    `json:"quantity"`
+	}{shipID, good, quantity}
+
+	return c.Request(ctx, Endpoint{http.MethodPost, path, true}, Params{"structureId": structureID}, nil, body, v)
+}
@@ -0,0 +1,135 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// WormholeLocationType is the type of locations that allow warp jumps
+const WormholeLocationType = "WORMHOLE"
+
+// Location is the model for a location in a system
+type Location struct {
+	Symbol             string   `json:"symbol"`
+	Type               string   `json:"type"`
+	Name               string   `json:"name"`
+	X                  int      `json:"x"`
+	Y                  int      `json:"y"`
+	AllowsConstruction bool     `json:"allowsConstruction"`
+	Messages           []string `json:"messages,omitempty"`
+}
+
+// System is the model for a system and its locations
+type System struct {
+	Symbol    string     `json:"symbol"`
+	Name      string     `json:"name"`
+	Locations []Location `json:"locations"`
+}
+
+// DockedShip is the model for a ship docked at a location, it can belong
+// to any user
+type DockedShip struct {
+	ShipID   string `json:"shipId"`
+	Username string `json:"username"`
+	ShipType string `json:"shipType"`
+}
+
+// Systems is the response model for /game/systems
+type Systems struct {
+	Systems []System `json:"systems"`
+}
+
+// Locations is the response model for /game/systems/:symbol/locations
+type Locations struct {
+	Locations []Location `json:"locations"`
+}
+
+// FetchedLocation is the response model for /game/locations/:symbol
+type FetchedLocation struct {
+	Location Location `json:"location"`
+}
+
+// LocationShips is the response model for /game/locations/:symbol/ships
+type LocationShips struct {
+	Location struct {
+		Ships []DockedShip `json:"ships"`
+	} `json:"location"`
+}
+
+// FetchSystems fetches the info of every system
+func (c Client) FetchSystems(ctx context.Context) (systems []System, err error) {
+	c.logger.Info("Fetching systems...")
+
+	var res Systems
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/systems", true}, nil, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching systems failed: ", err)
+		return
+	}
+	return res.Systems, err
+}
+
+// FetchLocations fetches the locations in system, locationType is an
+// optional filter and is ignored when empty.
+func (c Client) FetchLocations(ctx context.Context, system string, locationType string) (locations []Location, err error) {
+	c.logger.Infof("Fetching the locations in system %s...", system)
+
+	query := url.Values{}
+	if locationType != "" {
+		query.Set("type", locationType)
+	}
+
+	var res Locations
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/systems/:symbol/locations", true}, Params{"symbol": system}, query, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching locations failed: ", err)
+		return
+	}
+	return res.Locations, err
+}
+
+// FetchLocation fetches the location with symbol
+func (c Client) FetchLocation(ctx context.Context, symbol string) (location Location, err error) {
+	c.logger.Infof("Fetching location %s...", symbol)
+
+	var res FetchedLocation
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/locations/:symbol", true}, Params{"symbol": symbol}, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching location failed: ", err)
+		return
+	}
+	return res.Location, err
+}
+
+// FetchDockedShips fetches the ships docked at the location with symbol
+func (c Client) FetchDockedShips(ctx context.Context, symbol string) (ships []DockedShip, err error) {
+	c.logger.Infof("Fetching the ships docked at %s...", symbol)
+
+	var res LocationShips
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/game/locations/:symbol/ships", true}, Params{"symbol": symbol}, nil, nil, &res)
+	if err != nil {
+		c.logger.Error("Fetching docked ships failed: ", err)
+		return
+	}
+	return res.Location.Ships, err
+}
@@ -0,0 +1,111 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// GoodType is the model for a good that exists in the game
+type GoodType struct {
+	Symbol        string `json:"symbol"`
+	Name          string `json:"name"`
+	VolumePerUnit int    `json:"volumePerUnit"`
+}
+
+// ShipType is the model for a ship that exists in the game
+type ShipType struct {
+	Type         string `json:"type"`
+	Class        string `json:"class"`
+	Manufacturer string `json:"manufacturer"`
+	MaxCargo     int    `json:"maxCargo"`
+	Speed        int    `json:"speed"`
+	Plating      int    `json:"plating"`
+	Weapons      int    `json:"weapons"`
+}
+
+// StructureType is the model for a structure that can be built
+type StructureType struct {
+	Type                 string   `json:"type"`
+	Name                 string   `json:"name"`
+	Price                int64    `json:"price"`
+	AllowedLocationTypes []string `json:"allowedLocationTypes"`
+	Consumes             []string `json:"consumes"`
+	Produces             []string `json:"produces"`
+}
+
+// GoodTypes is the response model for /types/goods
+type GoodTypes struct {
+	Goods []GoodType `json:"goods"`
+}
+
+// ShipTypes is the response model for /types/ships
+type ShipTypes struct {
+	Ships []ShipType `json:"ships"`
+}
+
+// LoanTypes is the response model for /types/loans
+type LoanTypes struct {
+	Loans []AvailableLoan `json:"loans"`
+}
+
+// StructureTypes is the response model for /types/structures
+type StructureTypes struct {
+	Structures []StructureType `json:"structures"`
+}
+
+// FetchGoodTypes fetches every good in the game
+func (c Client) FetchGoodTypes(ctx context.Context) (goods []GoodType, err error) {
+	var res GoodTypes
+	err = c.fetchTypes(ctx, "/types/goods", &res)
+	return res.Goods, err
+}
+
+// FetchShipTypes fetches every ship in the game
+func (c Client) FetchShipTypes(ctx context.Context) (ships []ShipType, err error) {
+	var res ShipTypes
+	err = c.fetchTypes(ctx, "/types/ships", &res)
+	return res.Ships, err
+}
+
+// FetchLoanTypes fetches every loan in the game
+func (c Client) FetchLoanTypes(ctx context.Context) (loans []AvailableLoan, err error) {
+	var res LoanTypes
+	err = c.fetchTypes(ctx, "/types/loans", &res)
+	return res.Loans, err
+}
+
+// FetchStructureTypes fetches every structure in the game
+func (c Client) FetchStructureTypes(ctx context.Context) (structures []StructureType, err error) {
+	var res StructureTypes
+	err = c.fetchTypes(ctx, "/types/structures", &res)
+	return res.Structures, err
+}
+
+func (c Client) fetchTypes(ctx context.Context, path string, v interface{}) error {
+	c.logger.Infof("Fetching %s...", path)
+
+	err := c.Request(ctx, Endpoint{http.MethodGet, path, true}, nil, nil, nil, v)
+	if err != nil {
+		c.logger.Error("Fetching types failed: ", err)
+	}
+	return err
+}
@@ -20,17 +20,16 @@
 package api
 
 import (
-	"fmt"
+	"context"
 	"net/http"
-	"strings"
 )
 
 // InnerUser is the type embeded in user endpoint responses
 type InnerUser struct {
-	Username string                   `json:"username"`
-	Credits  int64                    `json:"credits"`
-	Ships    []map[string]interface{} `json:"ships"`
-	Loans    []map[string]interface{} `json:"loans"`
+	Username string `json:"username"`
+	Credits  int64  `json:"credits"`
+	Ships    []Ship `json:"ships"`
+	Loans    []Loan `json:"loans"`
 }
 
 // CreatedUser is the response model for /users/:username/token
@@ -45,14 +44,11 @@ type FetchedUser struct {
 }
 
 // CreateAccount creates an account with username
-func (c Client) CreateAccount(username string) (token string, err error) {
+func (c Client) CreateAccount(ctx context.Context, username string) (token string, err error) {
 	c.logger.Infof("Creating an account with username %s...", username)
 
-	url := BaseUrl + "/users/:username/token"
-	url = strings.Replace(url, ":username", username, 1)
-
 	var user CreatedUser
-	err = c.Do(url, http.MethodPost, nil, nil, &user)
+	err = c.Request(ctx, Endpoint{http.MethodPost, "/users/:username/token", false}, Params{"username": username}, nil, nil, &user)
 	if err != nil {
 		c.logger.Error("Creating account failed: ", err)
 		return
@@ -61,22 +57,15 @@ func (c Client) CreateAccount(username string) (token string, err error) {
 }
 
 // FetchAccount fetches an account with the username and token
-func (c Client) FetchAccount() (user FetchedUser, err error) {
-	if !c.HasAuth() {
-		err = fmt.Errorf("Client without auth only supports account creation and status")
-		return
-	}
-
+func (c Client) FetchAccount(ctx context.Context) (user FetchedUser, err error) {
 	c.logger.Infof("Fetching the account with username %s...", c.username)
 
-	url := BaseUrl + "/users/:username"
-	url = strings.Replace(url, ":username", c.username, 1)
-
-	headers := createAuthHeader(c.token)
-	err = c.Do(url, http.MethodGet, nil, headers, &user)
+	err = c.Request(ctx, Endpoint{http.MethodGet, "/users/:username", true}, nil, nil, nil, &user)
 	if err != nil {
 		c.logger.Error("Fetching user failed: ", err)
+		return
 	}
+	c.observeAccount(user.User)
 
 	return
 }
@@ -0,0 +1,156 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadFetchedUser(t *testing.T) (FetchedUser, []byte) {
+	t.Helper()
+
+	payload, err := os.ReadFile("testdata/fetched_user.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var user FetchedUser
+	if err := json.Unmarshal(payload, &user); err != nil {
+		t.Fatal(err)
+	}
+	return user, payload
+}
+
+func TestFetchedUserUnmarshal(t *testing.T) {
+	res, _ := loadFetchedUser(t)
+	user := res.User
+
+	if user.Username != "kraken" || user.Credits != 48275 {
+		t.Errorf("got user %s with %d credits", user.Username, user.Credits)
+	}
+
+	if len(user.Ships) != 2 {
+		t.Fatalf("got %d ships, want 2", len(user.Ships))
+	}
+	docked := Ship{
+		ID:           "ckpgnk3vd00750bs6r2ejhjtq",
+		Type:         "JW-MK-I",
+		Class:        "MK-I",
+		Manufacturer: "Jackshaw",
+		Location:     "OE-PM-TR",
+		X:            -20,
+		Y:            5,
+		Cargo: []Cargo{
+			{Good: "FUEL", Quantity: 20, TotalVolume: 20},
+			{Good: "METALS", Quantity: 30, TotalVolume: 30},
+		},
+		SpaceAvailable: 50,
+		Speed:          1,
+		Plating:        5,
+		Weapons:        5,
+		MaxCargo:       100,
+	}
+	if !reflect.DeepEqual(user.Ships[0], docked) {
+		t.Errorf("got docked ship %+v, want %+v", user.Ships[0], docked)
+	}
+	inTransit := user.Ships[1]
+	if inTransit.Location != "" {
+		t.Errorf("got location %q for a ship in transit", inTransit.Location)
+	}
+	if inTransit.Type != "GR-MK-I" || inTransit.MaxCargo != 300 || len(inTransit.Cargo) != 0 {
+		t.Errorf("got ship in transit %+v", inTransit)
+	}
+
+	if len(user.Loans) != 1 {
+		t.Fatalf("got %d loans, want 1", len(user.Loans))
+	}
+	loan := Loan{
+		ID:              "ckpgnj9ax00380bs6j6vm2k6p",
+		Type:            "STARTUP",
+		Status:          "CURRENT",
+		Due:             time.Date(2021, time.June, 7, 23, 9, 22, 651000000, time.UTC),
+		RepaymentAmount: 280000,
+	}
+	if !user.Loans[0].Due.Equal(loan.Due) {
+		t.Errorf("got due date %s, want %s", user.Loans[0].Due, loan.Due)
+	}
+	user.Loans[0].Due = loan.Due
+	if user.Loans[0] != loan {
+		t.Errorf("got loan %+v, want %+v", user.Loans[0], loan)
+	}
+}
+
+func TestFetchedUserRoundTrip(t *testing.T) {
+	user, _ := loadFetchedUser(t)
+
+	buf, err := json.Marshal(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded FetchedUser
+	if err := json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.User.Username != user.User.Username {
+		t.Errorf("username: got %q, want %q", decoded.User.Username, user.User.Username)
+	}
+	if decoded.User.Credits != user.User.Credits {
+		t.Errorf("credits: got %d, want %d", decoded.User.Credits, user.User.Credits)
+	}
+	if len(decoded.User.Ships) != len(user.User.Ships) {
+		t.Fatalf("got %d ships, want %d", len(decoded.User.Ships), len(user.User.Ships))
+	}
+	for i, ship := range user.User.Ships {
+		if !reflect.DeepEqual(decoded.User.Ships[i], ship) {
+			t.Errorf("ship %d: got %+v, want %+v", i, decoded.User.Ships[i], ship)
+		}
+	}
+	if len(decoded.User.Loans) != len(user.User.Loans) {
+		t.Fatalf("got %d loans, want %d", len(decoded.User.Loans), len(user.User.Loans))
+	}
+	for i, loan := range user.User.Loans {
+		got := decoded.User.Loans[i]
+		if !got.Due.Equal(loan.Due) {
+			t.Errorf("loan %d due: got %s, want %s", i, got.Due, loan.Due)
+		}
+		got.Due = loan.Due
+		if got != loan {
+			t.Errorf("loan %d: got %+v, want %+v", i, got, loan)
+		}
+	}
+
+	// a ship in transit has no location, it must not be sent back as ""
+	var raw struct {
+		User struct {
+			Ships []map[string]json.RawMessage `json:"ships"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(buf, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw.User.Ships[1]["location"]; ok {
+		t.Errorf("ship in transit was marshalled with a location: %s", strings.TrimSpace(string(buf)))
+	}
+}
@@ -0,0 +1,93 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CacheEntry is a cached api response.
+type CacheEntry struct {
+	Key       string `gorm:"primaryKey"`
+	Value     []byte
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// FetchCacheEntry returns the cached value of key, ok is false on a miss.
+func (c Client) FetchCacheEntry(key string) (value []byte, expiresAt time.Time, ok bool) {
+	var entry CacheEntry
+	tx := c.db.Where("key = ?", key).Limit(1).Find(&entry)
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		return
+	}
+	return entry.Value, entry.ExpiresAt, true
+}
+
+// SaveCacheEntry creates or replaces the cached value of key.
+func (c Client) SaveCacheEntry(key string, value []byte, expiresAt time.Time) error {
+	tx := c.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&CacheEntry{
+		Key:       key,
+		Value:     value,
+		ExpiresAt: expiresAt,
+	})
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+	}
+	return tx.Error
+}
+
+// DeleteCacheEntries deletes the entries whose key is matched along with
+// every expired entry.
+func (c Client) DeleteCacheEntries(match func(key string) bool) error {
+	var keys []string
+	if err := c.db.Model(&CacheEntry{}).Pluck("key", &keys).Error; err != nil {
+		c.logger.Error(err)
+		return err
+	}
+
+	var matched []string
+	for _, key := range keys {
+		if match(key) {
+			matched = append(matched, key)
+		}
+	}
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if len(matched) > 0 {
+			if err := tx.Where("key IN ?", matched).Delete(&CacheEntry{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("expires_at < ?", time.Now()).Delete(&CacheEntry{}).Error
+	})
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.logger.Error(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Names of the cached catalogs.
+const (
+	GoodsCatalog      = "goods"
+	ShipsCatalog      = "ships"
+	LoansCatalog      = "loans"
+	StructuresCatalog = "structures"
+)
+
+// CatalogRefresh holds the last time a catalog was refreshed.
+type CatalogRefresh struct {
+	Catalog     string `gorm:"primaryKey"`
+	RefreshedAt time.Time
+}
+
+// GoodType is a cached good.
+type GoodType struct {
+	Symbol        string `gorm:"primaryKey"`
+	Name          string
+	VolumePerUnit int
+}
+
+// ShipType is a cached ship type.
+type ShipType struct {
+	Type         string `gorm:"primaryKey"`
+	Class        string
+	Manufacturer string
+	MaxCargo     int
+	Speed        int
+	Plating      int
+	Weapons      int
+}
+
+// LoanType is a cached loan type.
+type LoanType struct {
+	Type               string `gorm:"primaryKey"`
+	Amount             int64
+	Rate               int
+	TermInDays         int
+	CollateralRequired bool
+}
+
+// StructureType is a cached structure type, list fields are comma separated.
+type StructureType struct {
+	Type                 string `gorm:"primaryKey"`
+	Name                 string
+	Price                int64
+	AllowedLocationTypes string
+	Consumes             string
+	Produces             string
+}
+
+// FetchCatalogRefresh returns when catalog was last refreshed, ok is false
+// if it was never refreshed.
+func (c Client) FetchCatalogRefresh(catalog string) (refreshedAt time.Time, ok bool) {
+	var refresh CatalogRefresh
+	tx := c.db.First(&refresh, "catalog = ?", catalog)
+	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		return
+	}
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+		return
+	}
+	return refresh.RefreshedAt, true
+}
+
+// ReplaceGoodTypes replaces the cached goods.
+func (c Client) ReplaceGoodTypes(goods []GoodType) error {
+	return c.replaceCatalog(GoodsCatalog, &GoodType{}, goods, len(goods))
+}
+
+// ReplaceShipTypes replaces the cached ship types.
+func (c Client) ReplaceShipTypes(ships []ShipType) error {
+	return c.replaceCatalog(ShipsCatalog, &ShipType{}, ships, len(ships))
+}
+
+// ReplaceLoanTypes replaces the cached loan types.
+func (c Client) ReplaceLoanTypes(loans []LoanType) error {
+	return c.replaceCatalog(LoansCatalog, &LoanType{}, loans, len(loans))
+}
+
+// ReplaceStructureTypes replaces the cached structure types.
+func (c Client) ReplaceStructureTypes(structures []StructureType) error {
+	return c.replaceCatalog(StructuresCatalog, &StructureType{}, structures, len(structures))
+}
+
+// FetchGoodTypes returns the cached goods.
+func (c Client) FetchGoodTypes() ([]GoodType, error) {
+	var goods []GoodType
+	tx := c.db.Order("symbol").Find(&goods)
+	return goods, tx.Error
+}
+
+// FetchShipTypes returns the cached ship types.
+func (c Client) FetchShipTypes() ([]ShipType, error) {
+	var ships []ShipType
+	tx := c.db.Order("type").Find(&ships)
+	return ships, tx.Error
+}
+
+// FetchLoanTypes returns the cached loan types.
+func (c Client) FetchLoanTypes() ([]LoanType, error) {
+	var loans []LoanType
+	tx := c.db.Order("type").Find(&loans)
+	return loans, tx.Error
+}
+
+// FetchStructureTypes returns the cached structure types.
+func (c Client) FetchStructureTypes() ([]StructureType, error) {
+	var structures []StructureType
+	tx := c.db.Order("type").Find(&structures)
+	return structures, tx.Error
+}
+
+// replaceCatalog deletes every row of model, inserts rows and updates the
+// refresh timestamp of catalog in a single transaction.
+func (c Client) replaceCatalog(catalog string, model interface{}, rows interface{}, count int) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			if err := tx.Create(rows).Error; err != nil {
+				return err
+			}
+		}
+		refresh := CatalogRefresh{Catalog: catalog, RefreshedAt: time.Now()}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&refresh).Error
+	})
+	if err != nil {
+		c.logger.Error(err)
+	}
+	return err
+}
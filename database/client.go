@@ -29,6 +29,7 @@ import (
 type Client struct {
 	db     *gorm.DB
 	logger log.Logger
+	tokens *tokenCipher
 }
 
 // New creates a new database client.
@@ -41,5 +42,16 @@ func New(db *gorm.DB, logger log.Logger) Client {
 
 // MigrateModels creates all the necessary tables in the database
 func (c Client) MigrateModels() {
-	c.db.AutoMigrate(&User{})
+	c.db.AutoMigrate(
+		&User{},
+		&ActiveUser{},
+		&Keyring{},
+		&CatalogRefresh{},
+		&GoodType{},
+		&ShipType{},
+		&LoanType{},
+		&StructureType{},
+		&RankSnapshot{},
+		&CacheEntry{},
+	)
 }
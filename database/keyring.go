@@ -0,0 +1,233 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// keyringID is the id of the only Keyring row
+	keyringID = 1
+
+	// encryptedPrefix marks the tokens that are encrypted
+	encryptedPrefix = "enc:"
+
+	// scrypt parameters recommended for interactive logins
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	keySize      = 32
+	saltSize     = 16
+	keyringCheck = "space-kraken"
+)
+
+var (
+	// ErrWrongPassphrase is returned when a passphrase doesn't match the one
+	// the tokens were encrypted with
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+
+	// ErrLocked is returned when the tokens are encrypted and the client
+	// wasn't unlocked
+	ErrLocked = errors.New("the tokens are encrypted, a passphrase is needed")
+)
+
+// Keyring is the salt of the key tokens are encrypted with, along with a
+// value encrypted with that key to check passphrases. There is no row when
+// tokens are stored in plaintext.
+type Keyring struct {
+	ID    uint `gorm:"primaryKey"`
+	Salt  []byte
+	Check string
+}
+
+// tokenCipher encrypts tokens with AES-GCM, the username is authenticated
+// along with each token so that it can't be moved to another account.
+type tokenCipher struct {
+	aead cipher.AEAD
+}
+
+func newTokenCipher(passphrase string, salt []byte) (*tokenCipher, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenCipher{aead}, nil
+}
+
+// encrypt seals plaintext, a nil cipher leaves it as is.
+func (t *tokenCipher) encrypt(plaintext string, username string) (string, error) {
+	if t == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, t.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := t.aead.Seal(nonce, nonce, []byte(plaintext), []byte(username))
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (t *tokenCipher) decrypt(value string, username string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", err
+	}
+	size := t.aead.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("encrypted token is too short")
+	}
+	plaintext, err := t.aead.Open(nil, sealed[:size], sealed[size:], []byte(username))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Encrypted reports whether the tokens are stored encrypted.
+func (c Client) Encrypted() bool {
+	_, ok := c.fetchKeyring()
+	return ok
+}
+
+// Unlock returns a client that decrypts the tokens with the key derived from
+// passphrase. ErrWrongPassphrase is returned if it isn't the right one.
+func (c Client) Unlock(passphrase string) (Client, error) {
+	keyring, ok := c.fetchKeyring()
+	if !ok {
+		return c, nil
+	}
+
+	tokens, err := newTokenCipher(passphrase, keyring.Salt)
+	if err != nil {
+		return c, err
+	}
+	if check, err := tokens.decrypt(keyring.Check, ""); err != nil || check != keyringCheck {
+		return c, ErrWrongPassphrase
+	}
+
+	c.tokens = tokens
+	return c, nil
+}
+
+// Rekey encrypts every token with a key derived from passphrase, an empty
+// passphrase stores them in plaintext. The client must be unlocked, the
+// returned client uses the new key.
+func (c Client) Rekey(passphrase string) (Client, error) {
+	if c.Encrypted() && c.tokens == nil {
+		return c, ErrLocked
+	}
+
+	var tokens *tokenCipher
+	var keyring Keyring
+	if passphrase != "" {
+		keyring = Keyring{ID: keyringID, Salt: make([]byte, saltSize)}
+		if _, err := io.ReadFull(rand.Reader, keyring.Salt); err != nil {
+			return c, err
+		}
+		var err error
+		if tokens, err = newTokenCipher(passphrase, keyring.Salt); err != nil {
+			return c, err
+		}
+		if keyring.Check, err = tokens.encrypt(keyringCheck, ""); err != nil {
+			return c, err
+		}
+	}
+
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		var users []User
+		if err := tx.Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			token, err := c.decryptToken(user)
+			if err != nil {
+				return err
+			}
+			if token, err = tokens.encrypt(token, user.Username); err != nil {
+				return err
+			}
+			if err := tx.Model(&user).Update("token", token).Error; err != nil {
+				return err
+			}
+		}
+
+		if tokens == nil {
+			return tx.Delete(&Keyring{}, keyringID).Error
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&keyring).Error
+	})
+	if err != nil {
+		c.logger.Error(err)
+		return c, err
+	}
+
+	c.tokens = tokens
+	return c, nil
+}
+
+func (c Client) fetchKeyring() (keyring Keyring, ok bool) {
+	tx := c.db.Limit(1).Find(&keyring, keyringID)
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+		return
+	}
+	return keyring, tx.RowsAffected != 0
+}
+
+// encryptToken prepares token to be saved, it is left as is when tokens are
+// stored in plaintext.
+func (c Client) encryptToken(username string, token string) (string, error) {
+	if c.tokens == nil && c.Encrypted() {
+		return "", ErrLocked
+	}
+	return c.tokens.encrypt(token, username)
+}
+
+// decryptToken returns the plaintext token of user, tokens saved before
+// encryption was enabled are returned as is.
+func (c Client) decryptToken(user User) (string, error) {
+	if !strings.HasPrefix(user.Token, encryptedPrefix) {
+		return user.Token, nil
+	}
+	if c.tokens == nil {
+		return "", ErrLocked
+	}
+	return c.tokens.decrypt(user.Token, user.Username)
+}
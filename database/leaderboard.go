@@ -0,0 +1,51 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package database
+
+import "time"
+
+// RankSnapshot is the rank and net worth of a user at the time it was fetched.
+type RankSnapshot struct {
+	ID        uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	Username  string `gorm:"index"`
+	Rank      int
+	NetWorth  int64
+}
+
+// CreateRankSnapshot saves the rank and net worth of username.
+func (c Client) CreateRankSnapshot(username string, rank int, netWorth int64) error {
+	tx := c.db.Create(&RankSnapshot{
+		Username: username,
+		Rank:     rank,
+		NetWorth: netWorth,
+	})
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+	}
+	return tx.Error
+}
+
+// FetchRankHistory returns every snapshot of username since since, oldest first.
+func (c Client) FetchRankHistory(username string, since time.Time) ([]RankSnapshot, error) {
+	var snapshots []RankSnapshot
+	tx := c.db.Where("username = ? AND created_at >= ?", username, since).Order("created_at").Find(&snapshots)
+	return snapshots, tx.Error
+}
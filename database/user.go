@@ -23,42 +23,135 @@ import (
 	"errors"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// User is a saved account, there is one row per username.
 type User struct {
 	gorm.Model
-	Username string
+	Username string `gorm:"uniqueIndex"`
 	Token    string
 }
 
+// ActiveUser points at the account that commands are run as, the table only
+// ever has the row with ID 1.
+type ActiveUser struct {
+	ID       uint `gorm:"primaryKey"`
+	Username string
+}
+
+// activeUserID is the id of the only ActiveUser row
+const activeUserID = 1
+
+// FetchUser returns the active account. Databases from before there could be
+// multiple accounts have no active account, the oldest one is used then.
 func (c Client) FetchUser() User {
+	var active ActiveUser
+	tx := c.db.Limit(1).Find(&active, activeUserID)
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+		return User{}
+	}
+	if tx.RowsAffected != 0 {
+		if user, ok := c.FetchUserByUsername(active.Username); ok {
+			return user
+		}
+	}
+
 	var user User
-	tx := c.db.First(&user)
+	tx = c.db.Order("id").First(&user)
 	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
 		return User{}
 	}
 	if tx.Error != nil {
 		c.logger.Error(tx.Error)
 	}
-	return user
+	return c.withPlaintextToken(user)
+}
+
+// FetchUserByUsername returns the account of username, ok is false if it
+// isn't saved.
+func (c Client) FetchUserByUsername(username string) (user User, ok bool) {
+	tx := c.db.Where("username = ?", username).Limit(1).Find(&user)
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		return
+	}
+	return c.withPlaintextToken(user), true
 }
 
+// FetchUsers returns every saved account ordered by username.
+func (c Client) FetchUsers() ([]User, error) {
+	var users []User
+	tx := c.db.Order("username").Find(&users)
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+	}
+	for i := range users {
+		users[i] = c.withPlaintextToken(users[i])
+	}
+	return users, tx.Error
+}
+
+// UpdateOrCreateUser saves the token of username, the other accounts are left
+// untouched. The token is encrypted when the client was unlocked.
 func (c Client) UpdateOrCreateUser(username string, token string) error {
-	var user User
-	tx := c.db.First(&user)
-	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-		c.db.Create(&User{
-			Username: username,
-			Token:    token,
-		})
-	} else if tx.Error != nil {
+	token, err := c.encryptToken(username, token)
+	if err != nil {
+		c.logger.Error(err)
+		return err
+	}
+
+	tx := c.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "username"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token", "updated_at"}),
+	}).Create(&User{
+		Username: username,
+		Token:    token,
+	})
+	if tx.Error != nil {
+		c.logger.Error(tx.Error)
+	}
+	return tx.Error
+}
+
+// SetActiveUser makes username the active account.
+func (c Client) SetActiveUser(username string) error {
+	tx := c.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&ActiveUser{
+		ID:       activeUserID,
+		Username: username,
+	})
+	if tx.Error != nil {
 		c.logger.Error(tx.Error)
-		return tx.Error
-	} else {
-		c.db.Model(&user).Updates(User{
-			Username: username,
-			Token:    token,
-		})
 	}
-	return nil
+	return tx.Error
+}
+
+// DeleteUser deletes the account of username, it stops being the active
+// account if it was.
+func (c Client) DeleteUser(username string) error {
+	err := c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("username = ?", username).Delete(&User{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("username = ?", username).Delete(&ActiveUser{}).Error
+	})
+	if err != nil {
+		c.logger.Error(err)
+	}
+	return err
+}
+
+// withPlaintextToken decrypts the token of user, it is emptied when that
+// fails so that a ciphertext is never sent to the api.
+func (c Client) withPlaintextToken(user User) User {
+	token, err := c.decryptToken(user)
+	if err != nil {
+		c.logger.Error(err)
+	}
+	user.Token = token
+	return user
 }
@@ -6,6 +6,8 @@ require (
 	github.com/komkom/toml v0.0.0-20210317065440-24f427ca88cc
 	github.com/magefile/mage v1.11.0
 	github.com/mattn/go-sqlite3 v1.14.6 // indirect
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
+	golang.org/x/term v0.0.0-20210422114643-f5beecf764ed
 	gorm.io/driver/sqlite v1.1.4
 	gorm.io/gorm v1.21.5
 )
@@ -0,0 +1,168 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yi-fan-song/space-kraken/database"
+)
+
+// catalogMaxAge is how long a cached catalog is used before it is refreshed.
+const catalogMaxAge = 24 * time.Hour
+
+var catalogs = []string{
+	database.GoodsCatalog,
+	database.ShipsCatalog,
+	database.LoansCatalog,
+	database.StructuresCatalog,
+}
+
+// refreshCatalog fetches catalog from the api and saves it to the database.
+func refreshCatalog(ctx context.Context, catalog string) error {
+	switch catalog {
+	case database.GoodsCatalog:
+		goods, err := gameClient.FetchGoodTypes(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([]database.GoodType, 0, len(goods))
+		for _, good := range goods {
+			rows = append(rows, database.GoodType{
+				Symbol:        good.Symbol,
+				Name:          good.Name,
+				VolumePerUnit: good.VolumePerUnit,
+			})
+		}
+		return dbClient.ReplaceGoodTypes(rows)
+
+	case database.ShipsCatalog:
+		ships, err := gameClient.FetchShipTypes(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([]database.ShipType, 0, len(ships))
+		for _, ship := range ships {
+			rows = append(rows, database.ShipType{
+				Type:         ship.Type,
+				Class:        ship.Class,
+				Manufacturer: ship.Manufacturer,
+				MaxCargo:     ship.MaxCargo,
+				Speed:        ship.Speed,
+				Plating:      ship.Plating,
+				Weapons:      ship.Weapons,
+			})
+		}
+		return dbClient.ReplaceShipTypes(rows)
+
+	case database.LoansCatalog:
+		loans, err := gameClient.FetchLoanTypes(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([]database.LoanType, 0, len(loans))
+		for _, loan := range loans {
+			rows = append(rows, database.LoanType{
+				Type:               loan.Type,
+				Amount:             loan.Amount,
+				Rate:               loan.Rate,
+				TermInDays:         loan.TermInDays,
+				CollateralRequired: loan.CollateralRequired,
+			})
+		}
+		return dbClient.ReplaceLoanTypes(rows)
+
+	case database.StructuresCatalog:
+		structures, err := gameClient.FetchStructureTypes(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([]database.StructureType, 0, len(structures))
+		for _, structure := range structures {
+			rows = append(rows, database.StructureType{
+				Type:                 structure.Type,
+				Name:                 structure.Name,
+				Price:                structure.Price,
+				AllowedLocationTypes: strings.Join(structure.AllowedLocationTypes, ","),
+				Consumes:             strings.Join(structure.Consumes, ","),
+				Produces:             strings.Join(structure.Produces, ","),
+			})
+		}
+		return dbClient.ReplaceStructureTypes(rows)
+	}
+
+	return fmt.Errorf("unknown catalog %q", catalog)
+}
+
+// ensureCatalog refreshes catalog if it was never fetched or is stale. It
+// returns false if there is no cached copy to validate against.
+func ensureCatalog(ctx context.Context, catalog string) bool {
+	refreshedAt, ok := dbClient.FetchCatalogRefresh(catalog)
+	if ok && time.Since(refreshedAt) < catalogMaxAge {
+		return true
+	}
+
+	if err := refreshCatalog(ctx, catalog); err != nil {
+		logger.Error("Could not refresh the ", catalog, " catalog: ", err)
+		return ok
+	}
+	return true
+}
+
+// validateGood returns an error if symbol is not a known good. Validation
+// is skipped when the catalog is unavailable.
+func validateGood(ctx context.Context, symbol string) error {
+	if !ensureCatalog(ctx, database.GoodsCatalog) {
+		return nil
+	}
+
+	goods, err := dbClient.FetchGoodTypes()
+	if err != nil {
+		return nil
+	}
+	for _, good := range goods {
+		if good.Symbol == symbol {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a known good, see \"types goods\"", symbol)
+}
+
+// validateShipType returns an error if shipType is not a known ship type.
+// Validation is skipped when the catalog is unavailable.
+func validateShipType(ctx context.Context, shipType string) error {
+	if !ensureCatalog(ctx, database.ShipsCatalog) {
+		return nil
+	}
+
+	ships, err := dbClient.FetchShipTypes()
+	if err != nil {
+		return nil
+	}
+	for _, ship := range ships {
+		if ship.Type == shipType {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a known ship type, see \"types ships\"", shipType)
+}
@@ -20,27 +20,66 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yi-fan-song/space-kraken/api"
+	"github.com/yi-fan-song/space-kraken/database"
 )
 
-func handleCmd(args []string) {
+func handleCmd(ctx context.Context, args []string) {
 	if len(args) == 0 {
 		return
 	}
 
 	switch args[0] {
 	case "status":
-		handleStatus()
+		handleStatus(ctx)
 	case "account":
-		handleAccount(args[1:])
+		handleAccount(ctx, args[1:])
+	case "loan":
+		handleLoan(ctx, args[1:])
+	case "ship":
+		handleShip(ctx, args[1:])
+	case "market":
+		handleMarket(ctx, args[1:])
+	case "buy":
+		handleOrder(ctx, args[1:], true)
+	case "sell":
+		handleOrder(ctx, args[1:], false)
+	case "fly":
+		handleFly(ctx, args[1:])
+	case "flight":
+		handleFlight(ctx, args[1:])
+	case "warp":
+		handleWarp(ctx, args[1:])
+	case "systems":
+		handleSystems(ctx)
+	case "locations":
+		handleLocations(ctx, args[1:])
+	case "location":
+		handleLocation(ctx, args[1:])
+	case "structure":
+		handleStructure(ctx, args[1:])
+	case "types":
+		handleTypes(ctx, args[1:])
+	case "cargo":
+		handleCargo(ctx, args[1:])
+	case "leaderboard":
+		handleLeaderboard(ctx, args[1:])
 	case "exit":
 		os.Exit(0)
 	}
 }
 
-func handleStatus() {
-	status, err := gameClient.FetchStatus()
+func handleStatus(ctx context.Context) {
+	status, err := gameClient.FetchStatus(ctx)
 	if err != nil {
 		fmt.Println("Failed to fetch status:", err)
 	} else {
@@ -48,7 +87,12 @@ func handleStatus() {
 	}
 }
 
-func handleAccount(args []string) {
+func handleAccount(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: account create <username>|login <username> <token>|token|list|switch <username>|remove <username>|rekey")
+		return
+	}
+
 	switch args[0] {
 	case "create":
 		if len(args[1:]) < 1 {
@@ -57,16 +101,12 @@ func handleAccount(args []string) {
 		}
 		username := args[1]
 
-		user := dbClient.FetchUser()
-		if user.Username != "" && user.Token != "" {
-			fmt.Printf("You are already logged in as %s, are you sure you want to create another account?\n", user.Username)
-			fmt.Printf("The token will be lost, cancel this command and use \"account token\" to retrive your token.\n")
-			if !promptForYes("Confirm [yes/no]?", nil) {
-				return
-			}
+		if _, ok := dbClient.FetchUserByUsername(username); ok {
+			fmt.Printf("The account %s is already saved, use \"account switch %s\" to use it.\n", username, username)
+			return
 		}
 
-		token, err := gameClient.CreateAccount(username)
+		token, err := gameClient.CreateAccount(ctx, username)
 		if err != nil {
 			fmt.Println("Could not create that account: ", err)
 			return
@@ -80,9 +120,9 @@ func handleAccount(args []string) {
 			return
 		}
 
-		gameClient.SetAuth(username, token)
 		fmt.Printf("Created account! Username: %s Token: %s\n", username, token)
 		fmt.Println("Make sure to keep that token safe")
+		switchAccount(username)
 
 	case "login":
 		if len(args[1:]) < 2 {
@@ -91,28 +131,24 @@ func handleAccount(args []string) {
 		}
 		username := args[1]
 		token := args[2]
-		gameClient.SetAuth(username, token)
 
-		if _, err := gameClient.FetchAccount(); err != nil {
+		client := newGameClient(database.User{Username: username, Token: token})
+		if _, err := client.FetchAccount(ctx); err != nil {
+			if errors.Is(err, api.ErrUnauthorized) {
+				fmt.Println("That token is not valid for that username.")
+				break
+			}
 			fmt.Printf("Could not verify username/token pair: %s\n", err.Error())
 			break
 		}
 
-		user := dbClient.FetchUser()
-		if user.Username != "" && user.Token != "" {
-			fmt.Printf("You are already logged in as %s, are you sure you want to login as %s?\n", user.Username, username)
-			fmt.Printf("The token will be lost, cancel this command and use \"account token\" to retrive your token.\n")
-			if !promptForYes("Confirm [yes/no]?", nil) {
-				gameClient.SetAuth(user.Username, user.Token)
-				return
-			}
-		}
-
 		if err := dbClient.UpdateOrCreateUser(username, token); err != nil {
 			fmt.Printf("Failed to save the account: %s.\n", err)
 			return
 		}
-		fmt.Printf("Successfully logged in as %s.\n", username)
+		if switchAccount(username) {
+			fmt.Printf("Successfully logged in as %s.\n", username)
+		}
 
 	case "token":
 		user := dbClient.FetchUser()
@@ -121,5 +157,970 @@ func handleAccount(args []string) {
 			return
 		}
 		fmt.Printf("Logged in with username: %s, token: %s.\n", user.Username, user.Token)
+
+	case "list":
+		users, err := dbClient.FetchUsers()
+		if err != nil {
+			fmt.Println("Failed to read the saved accounts:", err)
+			return
+		}
+		if len(users) == 0 {
+			fmt.Println("No account is saved yet.")
+			return
+		}
+
+		active := dbClient.FetchUser()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\tUSERNAME\tSAVED")
+		for _, user := range users {
+			marker := ""
+			if user.Username == active.Username {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", marker, user.Username, user.UpdatedAt.Local().Format(time.RFC822))
+		}
+		w.Flush()
+
+	case "switch":
+		if len(args[1:]) < 1 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		if switchAccount(args[1]) {
+			fmt.Printf("Switched to %s.\n", args[1])
+		}
+
+	case "remove":
+		if len(args[1:]) < 1 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		username := args[1]
+
+		user, ok := dbClient.FetchUserByUsername(username)
+		if !ok {
+			fmt.Printf("There is no saved account named %s.\n", username)
+			return
+		}
+
+		fmt.Printf("You are about to remove %s, its token is: %s\n", user.Username, user.Token)
+		fmt.Println("Keep the token if you want to log in to this account again.")
+		if !promptForYes("Confirm [yes/no]?", nil) {
+			return
+		}
+
+		wasActive := dbClient.FetchUser().Username == username
+		if err := dbClient.DeleteUser(username); err != nil {
+			fmt.Println("Failed to remove the account:", err)
+			return
+		}
+		fmt.Printf("Removed %s.\n", username)
+
+		if wasActive {
+			active := dbClient.FetchUser()
+			gameClient = newGameClient(active)
+			if active.Username == "" {
+				fmt.Println("No account is left, use \"account create\" or \"account login\" to add one.")
+			} else {
+				fmt.Printf("You are now logged in as %s.\n", active.Username)
+			}
+		}
+
+	case "rekey":
+		rekeyAccounts()
+
+	default:
+		fmt.Println("Usage: account create <username>|login <username> <token>|token|list|switch <username>|remove <username>|rekey")
+	}
+}
+
+// rekeyAccounts encrypts the saved tokens with a new passphrase, or stores
+// them in plaintext when it is left empty.
+func rekeyAccounts() {
+	if dbClient.Encrypted() {
+		current, err := readPassphrase("Current passphrase:")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if _, err := dbClient.Unlock(current); err != nil {
+			fmt.Println("Could not verify the passphrase:", err)
+			return
+		}
+	}
+
+	passphrase, err := readPassphrase("New passphrase, leave empty to store the tokens in plaintext:")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if passphrase == "" {
+		fmt.Println("Anyone who can read the database will be able to use your tokens.")
+		if !promptForYes("Confirm [yes/no]?", nil) {
+			return
+		}
+	} else {
+		repeated, err := readPassphrase("Repeat the new passphrase:")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if repeated != passphrase {
+			fmt.Println("The passphrases don't match.")
+			return
+		}
+	}
+
+	rekeyed, err := dbClient.Rekey(passphrase)
+	if err != nil {
+		fmt.Println("Failed to rekey the saved tokens:", err)
+		return
+	}
+	dbClient = rekeyed
+
+	if passphrase == "" {
+		fmt.Println("The saved tokens are now stored in plaintext.")
+	} else {
+		fmt.Println("The saved tokens are now encrypted, the passphrase will be asked for at startup.")
+		fmt.Printf("Update %s if you set it to run without a terminal.\n", passphraseEnv)
+	}
+}
+
+// switchAccount makes the saved account of username active and rebuilds
+// gameClient with its token.
+func switchAccount(username string) bool {
+	user, ok := dbClient.FetchUserByUsername(username)
+	if !ok {
+		fmt.Printf("There is no saved account named %s, use \"account list\" to see them.\n", username)
+		return false
+	}
+	if err := dbClient.SetActiveUser(user.Username); err != nil {
+		fmt.Println("Failed to switch account:", err)
+		return false
+	}
+
+	gameClient = newGameClient(user)
+	return true
+}
+
+func handleLoan(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: loan list|available|take <type>|pay <id>")
+		return
+	}
+
+	switch args[0] {
+	case "list":
+		loans, err := gameClient.FetchLoans(ctx)
+		if err != nil {
+			fmt.Println("Failed to fetch loans:", err)
+			return
+		}
+		if len(loans) == 0 {
+			fmt.Println("You don't have any loans.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tSTATUS\tDUE\tREPAYMENT")
+		for _, loan := range loans {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", loan.ID, loan.Type, loan.Status, loan.Due.Local().Format("Jan _2 15:04"), loan.RepaymentAmount)
+		}
+		w.Flush()
+
+	case "available":
+		loans, err := gameClient.FetchAvailableLoans(ctx)
+		if err != nil {
+			fmt.Println("Failed to fetch available loans:", err)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TYPE\tAMOUNT\tRATE\tTERM (DAYS)\tCOLLATERAL")
+		for _, loan := range loans {
+			fmt.Fprintf(w, "%s\t%d\t%d%%\t%d\t%t\n", loan.Type, loan.Amount, loan.Rate, loan.TermInDays, loan.CollateralRequired)
+		}
+		w.Flush()
+
+	case "take":
+		if len(args[1:]) < 1 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		loanType := args[1]
+
+		fmt.Printf("You are about to take a loan of type %s.\n", loanType)
+		if !promptForYes("Confirm [yes/no]?", nil) {
+			return
+		}
+
+		loan, err := gameClient.TakeLoan(ctx, loanType)
+		if err != nil {
+			fmt.Println("Could not take that loan:", err)
+			return
+		}
+		fmt.Printf("Took loan %s, %d credits are due on %s.\n", loan.Loan.ID, loan.Loan.RepaymentAmount, loan.Loan.Due.Local().Format("Jan _2 15:04"))
+		fmt.Printf("You now have %d credits.\n", loan.Credits)
+
+	case "pay":
+		if len(args[1:]) < 1 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		loanID := args[1]
+
+		fmt.Printf("You are about to pay off loan %s.\n", loanID)
+		if !promptForYes("Confirm [yes/no]?", nil) {
+			return
+		}
+
+		user, err := gameClient.PayLoan(ctx, loanID)
+		if err != nil {
+			fmt.Println("Could not pay that loan:", err)
+			return
+		}
+		fmt.Printf("Paid off loan %s, you now have %d credits.\n", loanID, user.User.Credits)
+
+	default:
+		fmt.Printf("Unknown loan command %q.\n", args[0])
+	}
+}
+
+func handleShip(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: ship listings [class]|buy <location> <type>|scrap <id>")
+		return
+	}
+
+	switch args[0] {
+	case "listings":
+		var class string
+		if len(args[1:]) > 0 {
+			class = args[1]
+		}
+
+		listings, err := gameClient.FetchShipListings(ctx, class, "")
+		if err != nil {
+			fmt.Println("Failed to fetch ship listings:", err)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TYPE\tMANUFACTURER\tSPEED\tCARGO\tLOCATION\tPRICE")
+		for _, listing := range listings {
+			for i, loc := range listing.PurchaseLocations {
+				if i == 0 {
+					fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%d\n", listing.Type, listing.Manufacturer, listing.Speed, listing.MaxCargo, loc.Location, loc.Price)
+				} else {
+					fmt.Fprintf(w, "\t\t\t\t%s\t%d\n", loc.Location, loc.Price)
+				}
+			}
+		}
+		w.Flush()
+
+	case "buy":
+		if len(args[1:]) < 2 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		location := args[1]
+		shipType := args[2]
+
+		if err := validateShipType(ctx, shipType); err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		ship, err := gameClient.BuyShip(ctx, location, shipType)
+		if err != nil {
+			fmt.Println("Could not buy that ship:", err)
+			return
+		}
+		fmt.Printf("Bought a %s, its id is %s.\n", ship.Ship.Type, ship.Ship.ID)
+		fmt.Printf("You now have %d credits.\n", ship.Credits)
+
+	case "scrap":
+		if len(args[1:]) < 1 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		shipID := args[1]
+
+		fmt.Printf("You are about to scrap ship %s, this cannot be undone.\n", shipID)
+		if !promptForYes("Confirm [yes/no]?", nil) {
+			return
+		}
+
+		scrapped, err := gameClient.ScrapShip(ctx, shipID)
+		if err != nil {
+			fmt.Println("Could not scrap that ship:", err)
+			return
+		}
+		fmt.Println(scrapped.Success)
+
+	default:
+		fmt.Printf("Unknown ship command %q.\n", args[0])
+	}
+}
+
+func handleMarket(ctx context.Context, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: market <location> [symbol|spread|volume]")
+		return
+	}
+	location := args[0]
+
+	sortBy := "symbol"
+	if len(args[1:]) > 0 {
+		sortBy = args[1]
+	}
+
+	goods, err := gameClient.FetchMarketplace(ctx, location)
+	if err != nil {
+		fmt.Println("Failed to fetch marketplace:", err)
+		return
+	}
+
+	switch sortBy {
+	case "symbol":
+		sort.Slice(goods, func(i, j int) bool { return goods[i].Symbol < goods[j].Symbol })
+	case "spread":
+		sort.Slice(goods, func(i, j int) bool { return goods[i].Spread < goods[j].Spread })
+	case "volume":
+		sort.Slice(goods, func(i, j int) bool { return goods[i].VolumePerUnit < goods[j].VolumePerUnit })
+	default:
+		fmt.Printf("Cannot sort by %q, use symbol, spread or volume.\n", sortBy)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tVOLUME\tPRICE\tPURCHASE\tSELL\tSPREAD\tAVAILABLE")
+	for _, good := range goods {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n", good.Symbol, good.VolumePerUnit, good.PricePerUnit, good.PurchasePricePerUnit, good.SellPricePerUnit, good.Spread, good.QuantityAvailable)
+	}
+	w.Flush()
+}
+
+// handleOrder places a purchase order when buying is true and a sell order
+// otherwise, after checking that the order can go through.
+func handleOrder(ctx context.Context, args []string, buying bool) {
+	if len(args) < 3 {
+		fmt.Println("There are not enough arguments")
+		return
+	}
+	shipID := args[0]
+	good := args[1]
+	quantity, err := strconv.Atoi(args[2])
+	if err != nil || quantity <= 0 {
+		fmt.Printf("%q is not a valid quantity.\n", args[2])
+		return
+	}
+	if err := validateGood(ctx, good); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	user, err := gameClient.FetchAccount(ctx)
+	if err != nil {
+		fmt.Println("Failed to fetch account:", err)
+		return
+	}
+	ship, ok := findShip(user.User.Ships, shipID)
+	if !ok {
+		fmt.Printf("You don't own a ship with id %s.\n", shipID)
+		return
+	}
+	if ship.Location == "" {
+		fmt.Printf("Ship %s is in transit and cannot trade.\n", shipID)
+		return
+	}
+
+	goods, err := gameClient.FetchMarketplace(ctx, ship.Location)
+	if err != nil {
+		fmt.Println("Failed to fetch marketplace:", err)
+		return
+	}
+	marketGood, ok := findMarketGood(goods, good)
+	if !ok {
+		fmt.Printf("%s is not traded at %s.\n", good, ship.Location)
+		return
+	}
+
+	var order api.PlacedOrder
+	if buying {
+		if volume := quantity * marketGood.VolumePerUnit; volume > ship.SpaceAvailable {
+			fmt.Printf("Ship %s only has %d cargo space left, %d %s needs %d.\n", shipID, ship.SpaceAvailable, quantity, good, volume)
+			return
+		}
+		if cost := int64(quantity) * marketGood.PurchasePricePerUnit; cost > user.User.Credits {
+			fmt.Printf("You only have %d credits, %d %s costs %d.\n", user.User.Credits, quantity, good, cost)
+			return
+		}
+		order, err = gameClient.PlacePurchaseOrder(ctx, shipID, good, quantity)
+	} else {
+		if held := cargoQuantity(ship, good); held < quantity {
+			fmt.Printf("Ship %s only holds %d %s.\n", shipID, held, good)
+			return
+		}
+		order, err = gameClient.PlaceSellOrder(ctx, shipID, good, quantity)
+	}
+	switch {
+	case errors.Is(err, api.ErrInsufficientFunds):
+		fmt.Println("You don't have enough credits for that order.")
+		return
+	case errors.Is(err, api.ErrCargoFull):
+		fmt.Printf("Ship %s doesn't have enough cargo space for that order.\n", shipID)
+		return
+	case err != nil:
+		fmt.Println("The order failed:", err)
+		return
+	}
+
+	fmt.Printf("Traded %d %s at %d per unit for a total of %d.\n", order.Order.Quantity, order.Order.Good, order.Order.PricePerUnit, order.Order.Total)
+	fmt.Printf("You now have %d credits, ship %s has %d cargo space left.\n", order.Credits, order.Ship.ID, order.Ship.SpaceAvailable)
+}
+
+func findShip(ships []api.Ship, id string) (api.Ship, bool) {
+	for _, ship := range ships {
+		if ship.ID == id {
+			return ship, true
+		}
+	}
+	return api.Ship{}, false
+}
+
+func findMarketGood(goods []api.MarketGood, symbol string) (api.MarketGood, bool) {
+	for _, good := range goods {
+		if good.Symbol == symbol {
+			return good, true
+		}
+	}
+	return api.MarketGood{}, false
+}
+
+func cargoQuantity(ship api.Ship, good string) int {
+	quantity := 0
+	for _, cargo := range ship.Cargo {
+		if cargo.Good == good {
+			quantity += cargo.Quantity
+		}
+	}
+	return quantity
+}
+
+func handleFly(ctx context.Context, args []string) {
+	if len(args) < 2 {
+		fmt.Println("There are not enough arguments")
+		return
+	}
+	shipID := args[0]
+	destination := args[1]
+
+	plan, err := gameClient.CreateFlightPlan(ctx, shipID, destination)
+	if err != nil {
+		fmt.Println("Could not create that flight plan:", err)
+		return
+	}
+
+	printFlightPlan(plan)
+	fmt.Printf("Press Ctrl-C to stop waiting, \"flight %s\" resumes the countdown.\n", plan.ID)
+	waitForArrival(ctx, plan)
+}
+
+func handleFlight(ctx context.Context, args []string) {
+	if len(args) < 1 {
+		fmt.Println("There are not enough arguments")
+		return
+	}
+
+	plan, err := gameClient.FetchFlightPlan(ctx, args[0])
+	if err != nil {
+		fmt.Println("Failed to fetch flight plan:", err)
+		return
+	}
+
+	printFlightPlan(plan)
+	waitForArrival(ctx, plan)
+}
+
+func handleWarp(ctx context.Context, args []string) {
+	if len(args) < 1 {
+		fmt.Println("There are not enough arguments")
+		return
+	}
+	shipID := args[0]
+
+	user, err := gameClient.FetchAccount(ctx)
+	if err != nil {
+		fmt.Println("Failed to fetch account:", err)
+		return
+	}
+	ship, ok := findShip(user.User.Ships, shipID)
+	if !ok {
+		fmt.Printf("You don't own a ship with id %s.\n", shipID)
+		return
+	}
+	if ship.Location == "" {
+		fmt.Printf("Ship %s is in transit, it must be docked at a wormhole to warp.\n", shipID)
+		return
+	}
+
+	location, err := gameClient.FetchLocation(ctx, ship.Location)
+	if err != nil {
+		fmt.Println("Failed to fetch location:", err)
+		return
+	}
+	if location.Type != api.WormholeLocationType {
+		fmt.Printf("Ship %s is docked at %s which is not a wormhole.\n", shipID, location.Symbol)
+		return
+	}
+
+	plan, err := gameClient.WarpJump(ctx, shipID)
+	if err != nil {
+		fmt.Println("The warp jump failed:", err)
+		return
+	}
+
+	printFlightPlan(plan)
+	fmt.Printf("Press Ctrl-C to stop waiting, \"flight %s\" resumes the countdown.\n", plan.ID)
+	waitForArrival(ctx, plan)
+}
+
+func printFlightPlan(plan api.FlightPlan) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Flight plan:\t%s\n", plan.ID)
+	fmt.Fprintf(w, "Ship:\t%s\n", plan.ShipID)
+	fmt.Fprintf(w, "Departure:\t%s\n", plan.Departure)
+	fmt.Fprintf(w, "Destination:\t%s\n", plan.Destination)
+	fmt.Fprintf(w, "Distance:\t%d\n", plan.Distance)
+	fmt.Fprintf(w, "Fuel consumed:\t%d\n", plan.FuelConsumed)
+	fmt.Fprintf(w, "Arrives at:\t%s\n", plan.ArrivesAt.Local().Format("Jan _2 15:04:05"))
+	w.Flush()
+}
+
+// waitForArrival counts down until the flight plan arrives.
+func waitForArrival(ctx context.Context, plan api.FlightPlan) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		remaining := time.Until(plan.ArrivesAt).Round(time.Second)
+		if remaining <= 0 || plan.TerminatedAt != nil {
+			fmt.Printf("\rShip %s has arrived at %s.          \n", plan.ShipID, plan.Destination)
+			return
+		}
+		fmt.Printf("\rTime remaining: %s   ", remaining)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			fmt.Println()
+			return
+		}
+	}
+}
+
+func handleSystems(ctx context.Context) {
+	systems, err := gameClient.FetchSystems(ctx)
+	if err != nil {
+		fmt.Println("Failed to fetch systems:", err)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tNAME\tLOCATIONS")
+	for _, system := range systems {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", system.Symbol, system.Name, len(system.Locations))
+	}
+	w.Flush()
+}
+
+func handleLocations(ctx context.Context, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: locations <system> [type]")
+		return
+	}
+	system := args[0]
+
+	var locationType string
+	if len(args[1:]) > 0 {
+		locationType = args[1]
+	}
+
+	locations, err := gameClient.FetchLocations(ctx, system, locationType)
+	if err != nil {
+		fmt.Println("Failed to fetch locations:", err)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tTYPE\tNAME\tX\tY")
+	for _, location := range locations {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", location.Symbol, location.Type, location.Name, location.X, location.Y)
+	}
+	w.Flush()
+}
+
+func handleLocation(ctx context.Context, args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: location <symbol>")
+		return
+	}
+	symbol := args[0]
+
+	location, err := gameClient.FetchLocation(ctx, symbol)
+	if err != nil {
+		fmt.Println("Failed to fetch location:", err)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Symbol:\t%s\n", location.Symbol)
+	fmt.Fprintf(w, "Name:\t%s\n", location.Name)
+	fmt.Fprintf(w, "Type:\t%s\n", location.Type)
+	fmt.Fprintf(w, "Coordinates:\t%d, %d\n", location.X, location.Y)
+	fmt.Fprintf(w, "Allows construction:\t%t\n", location.AllowsConstruction)
+	w.Flush()
+	for _, message := range location.Messages {
+		fmt.Println(message)
+	}
+
+	ships, err := gameClient.FetchDockedShips(ctx, symbol)
+	if err != nil {
+		fmt.Println("Failed to fetch docked ships:", err)
+		return
+	}
+	if len(ships) == 0 {
+		fmt.Println("No ships are docked here.")
+		return
+	}
+
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SHIP\tTYPE\tOWNER")
+	for _, ship := range ships {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ship.ShipID, ship.ShipType, ship.Username)
+	}
+	w.Flush()
+}
+
+func handleStructure(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: structure list|build <location> <type>|deposit <structureId> <shipId> <good> <qty>|transfer <structureId> <shipId> <good> <qty>")
+		return
+	}
+
+	switch args[0] {
+	case "list":
+		structures, err := gameClient.FetchStructures(ctx)
+		if err != nil {
+			fmt.Println("Failed to fetch structures:", err)
+			return
+		}
+		if len(structures) == 0 {
+			fmt.Println("You don't own any structures.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tLOCATION\tACTIVE\tSTATUS")
+		for _, structure := range structures {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", structure.ID, structure.Type, structure.Location, structure.Active, structure.Status)
+			for _, goods := range structure.Inventory {
+				fmt.Fprintf(w, "\t%s\t%d\t\t\n", goods.Good, goods.Quantity)
+			}
+		}
+		w.Flush()
+
+	case "build":
+		if len(args[1:]) < 2 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		location := args[1]
+		structureType := args[2]
+
+		structure, err := gameClient.CreateStructure(ctx, location, structureType)
+		if err != nil {
+			fmt.Println("Could not build that structure:", err)
+			return
+		}
+		fmt.Printf("Built a %s at %s, its id is %s.\n", structure.Type, structure.Location, structure.ID)
+
+	case "deposit", "transfer":
+		if len(args[1:]) < 4 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		structureID := args[1]
+		shipID := args[2]
+		good := args[3]
+		quantity, err := strconv.Atoi(args[4])
+		if err != nil || quantity <= 0 {
+			fmt.Printf("%q is not a valid quantity.\n", args[4])
+			return
+		}
+
+		var (
+			moved     api.Goods
+			ship      api.Ship
+			structure api.Structure
+		)
+		if args[0] == "deposit" {
+			var deposit api.StructureDeposit
+			deposit, err = gameClient.DepositGoods(ctx, structureID, shipID, good, quantity)
+			moved, ship, structure = deposit.Deposit, deposit.Ship, deposit.Structure
+		} else {
+			var transfer api.StructureTransfer
+			transfer, err = gameClient.TransferGoods(ctx, structureID, shipID, good, quantity)
+			moved, ship, structure = transfer.Transfer, transfer.Ship, transfer.Structure
+		}
+		if err != nil {
+			fmt.Printf("Could not %s those goods: %s\n", args[0], err)
+			return
+		}
+		fmt.Printf("Moved %d %s, ship %s has %d cargo space left.\n", moved.Quantity, moved.Good, ship.ID, ship.SpaceAvailable)
+		fmt.Printf("Structure %s now holds:\n", structure.ID)
+		for _, goods := range structure.Inventory {
+			fmt.Printf("  %s: %d\n", goods.Good, goods.Quantity)
+		}
+
+	default:
+		fmt.Printf("Unknown structure command %q.\n", args[0])
+	}
+}
+
+func handleTypes(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: types goods|ships|loans|structures|refresh")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	switch args[0] {
+	case "refresh":
+		for _, catalog := range catalogs {
+			if err := refreshCatalog(ctx, catalog); err != nil {
+				fmt.Printf("Failed to refresh %s: %s\n", catalog, err)
+				return
+			}
+		}
+		fmt.Println("Refreshed every catalog.")
+		return
+
+	case "goods":
+		goods, err := dbClient.FetchGoodTypes()
+		if err != nil {
+			fmt.Println("Failed to read goods:", err)
+			return
+		}
+		fmt.Fprintln(w, "SYMBOL\tNAME\tVOLUME")
+		for _, good := range goods {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", good.Symbol, good.Name, good.VolumePerUnit)
+		}
+
+	case "ships":
+		ships, err := dbClient.FetchShipTypes()
+		if err != nil {
+			fmt.Println("Failed to read ship types:", err)
+			return
+		}
+		fmt.Fprintln(w, "TYPE\tCLASS\tMANUFACTURER\tCARGO\tSPEED\tPLATING\tWEAPONS")
+		for _, ship := range ships {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n", ship.Type, ship.Class, ship.Manufacturer, ship.MaxCargo, ship.Speed, ship.Plating, ship.Weapons)
+		}
+
+	case "loans":
+		loans, err := dbClient.FetchLoanTypes()
+		if err != nil {
+			fmt.Println("Failed to read loan types:", err)
+			return
+		}
+		fmt.Fprintln(w, "TYPE\tAMOUNT\tRATE\tTERM (DAYS)\tCOLLATERAL")
+		for _, loan := range loans {
+			fmt.Fprintf(w, "%s\t%d\t%d%%\t%d\t%t\n", loan.Type, loan.Amount, loan.Rate, loan.TermInDays, loan.CollateralRequired)
+		}
+
+	case "structures":
+		structures, err := dbClient.FetchStructureTypes()
+		if err != nil {
+			fmt.Println("Failed to read structure types:", err)
+			return
+		}
+		fmt.Fprintln(w, "TYPE\tNAME\tPRICE\tLOCATIONS\tCONSUMES\tPRODUCES")
+		for _, structure := range structures {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", structure.Type, structure.Name, structure.Price, structure.AllowedLocationTypes, structure.Consumes, structure.Produces)
+		}
+
+	default:
+		fmt.Printf("Unknown catalog %q.\n", args[0])
+		return
+	}
+
+	if refreshedAt, ok := dbClient.FetchCatalogRefresh(args[0]); ok {
+		fmt.Fprintf(w, "\nLast refreshed %s, use \"types refresh\" to update.\n", refreshedAt.Local().Format("Jan _2 15:04"))
+	} else {
+		fmt.Fprintln(w, "\nThis catalog was never fetched, use \"types refresh\" to fetch it.")
+	}
+}
+
+func handleCargo(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: cargo jettison <shipId> <good> <qty>|transfer <from> <to> <good> <qty>")
+		return
+	}
+
+	switch args[0] {
+	case "jettison":
+		if len(args[1:]) < 3 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		shipID := args[1]
+		good := args[2]
+		quantity, err := strconv.Atoi(args[3])
+		if err != nil || quantity <= 0 {
+			fmt.Printf("%q is not a valid quantity.\n", args[3])
+			return
+		}
+
+		fmt.Printf("You are about to jettison %d %s from ship %s, it will be lost.\n", quantity, good, shipID)
+		if !promptForYes("Confirm [yes/no]?", nil) {
+			return
+		}
+
+		jettisoned, err := gameClient.JettisonCargo(ctx, shipID, good, quantity)
+		if err != nil {
+			fmt.Println("Could not jettison that cargo:", err)
+			return
+		}
+		fmt.Printf("Jettisoned %s, ship %s has %d left.\n", jettisoned.Good, jettisoned.ShipID, jettisoned.QuantityRemaining)
+
+	case "transfer":
+		if len(args[1:]) < 4 {
+			fmt.Println("There are not enough arguments")
+			break
+		}
+		fromShipID := args[1]
+		toShipID := args[2]
+		good := args[3]
+		quantity, err := strconv.Atoi(args[4])
+		if err != nil || quantity <= 0 {
+			fmt.Printf("%q is not a valid quantity.\n", args[4])
+			return
+		}
+
+		user, err := gameClient.FetchAccount(ctx)
+		if err != nil {
+			fmt.Println("Failed to fetch account:", err)
+			return
+		}
+		fromShip, ok := findShip(user.User.Ships, fromShipID)
+		if !ok {
+			fmt.Printf("You don't own a ship with id %s.\n", fromShipID)
+			return
+		}
+		toShip, ok := findShip(user.User.Ships, toShipID)
+		if !ok {
+			fmt.Printf("You don't own a ship with id %s.\n", toShipID)
+			return
+		}
+		if fromShip.Location == "" || fromShip.Location != toShip.Location {
+			fmt.Println("Both ships must be docked at the same location.")
+			return
+		}
+
+		transfered, err := gameClient.TransferCargo(ctx, fromShipID, toShipID, good, quantity)
+		if err != nil {
+			fmt.Println("Could not transfer that cargo:", err)
+			return
+		}
+		fmt.Printf("Transfered %d %s from ship %s to ship %s.\n", quantity, good, transfered.FromShip.ID, transfered.ToShip.ID)
+		fmt.Printf("Ship %s has %d cargo space left.\n", transfered.ToShip.ID, transfered.ToShip.SpaceAvailable)
+
+	default:
+		fmt.Printf("Unknown cargo command %q.\n", args[0])
+	}
+}
+
+func handleLeaderboard(ctx context.Context, args []string) {
+	if len(args) > 0 && args[0] == "history" {
+		days := 7
+		if len(args[1:]) > 0 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				fmt.Printf("%q is not a valid number of days.\n", args[1])
+				return
+			}
+			days = n
+		}
+		printRankHistory(days)
+		return
+	}
+
+	leaderboard, err := gameClient.FetchLeaderboard(ctx)
+	if err != nil {
+		fmt.Println("Failed to fetch leaderboard:", err)
+		return
+	}
+
+	own := leaderboard.UserNetWorth
+	if own.Username != "" {
+		if err := dbClient.CreateRankSnapshot(own.Username, own.Rank, own.NetWorth); err != nil {
+			fmt.Println("Failed to save your rank:", err)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RANK\tUSERNAME\tNET WORTH")
+	for _, entry := range leaderboard.NetWorth {
+		fmt.Fprintf(w, "%d\t%s\t%d\n", entry.Rank, entry.Username, entry.NetWorth)
+	}
+	if own.Username != "" {
+		fmt.Fprintf(w, "\t\t\n%d\t%s (you)\t%d\n", own.Rank, own.Username, own.NetWorth)
+	}
+	w.Flush()
+}
+
+// printRankHistory prints the last snapshot of each of the past days.
+func printRankHistory(days int) {
+	user := dbClient.FetchUser()
+	if user.Username == "" {
+		fmt.Println("You haven't logged in yet.")
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	snapshots, err := dbClient.FetchRankHistory(user.Username, since)
+	if err != nil {
+		fmt.Println("Failed to read rank history:", err)
+		return
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No rank history yet, use \"leaderboard\" to record your rank.")
+		return
+	}
+
+	// keep the latest snapshot of each day
+	var daily []database.RankSnapshot
+	for _, snapshot := range snapshots {
+		day := snapshot.CreatedAt.Local().Format("2006-01-02")
+		if len(daily) > 0 && daily[len(daily)-1].CreatedAt.Local().Format("2006-01-02") == day {
+			daily[len(daily)-1] = snapshot
+		} else {
+			daily = append(daily, snapshot)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAY\tRANK\tCHANGE\tNET WORTH\tCHANGE")
+	for i, snapshot := range daily {
+		var rankChange, worthChange string
+		if i > 0 {
+			rankChange = fmt.Sprintf("%+d", daily[i-1].Rank-snapshot.Rank)
+			worthChange = fmt.Sprintf("%+d", snapshot.NetWorth-daily[i-1].NetWorth)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%s\n", snapshot.CreatedAt.Local().Format("Mon Jan _2"), snapshot.Rank, rankChange, snapshot.NetWorth, worthChange)
 	}
+	w.Flush()
 }
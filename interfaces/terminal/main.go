@@ -21,17 +21,21 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"time"
 
 	"github.com/komkom/toml"
 	"github.com/yi-fan-song/space-kraken/api"
+	"github.com/yi-fan-song/space-kraken/api/cassette"
 	"github.com/yi-fan-song/space-kraken/database"
 	"github.com/yi-fan-song/space-kraken/log"
+	"github.com/yi-fan-song/space-kraken/metrics"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
@@ -42,14 +46,24 @@ const (
 	configPath = configDir + "/settings.toml"
 	dataPath   = configDir + "/data"
 	logPath    = configDir + "/latest.log"
+
+	// passphraseEnv holds the passphrase of the saved tokens when there is
+	// no terminal to prompt for it
+	passphraseEnv = "SPACE_KRAKEN_PASSPHRASE"
+
+	// defaultMetricsAddress is the port exposed by the docker image
+	defaultMetricsAddress = ":8000"
 )
 
 var (
 	logger log.Logger
 
 	httpClient http.Client
+	recorder   *cassette.Transport
+	clientOpts []api.Option
 	gameClient api.Client
 	dbClient   database.Client
+	registry   *metrics.Registry
 
 	settings Settings
 )
@@ -81,17 +95,81 @@ func init() {
 	if err != nil {
 		panic("failed to connect database")
 	}
+	// the database holds the tokens of every account
+	if err := os.Chmod(dataPath, 0600); err != nil {
+		logger.Error(err)
+	}
 
 	dbClient = database.New(db, logger)
 	dbClient.MigrateModels()
+	if dbClient.Encrypted() {
+		dbClient, err = unlockDatabase(dbClient)
+		if err != nil {
+			fmt.Println("Could not unlock the saved tokens:", err)
+			os.Exit(1)
+		}
+	}
 	user := dbClient.FetchUser()
 
 	httpClient = http.Client{Timeout: time.Minute}
-	gameClient = api.New(user.Username, user.Token, &httpClient, logger)
+	if settings.Api.Cassette != "" {
+		mode := cassette.Replay
+		if settings.Api.Record {
+			mode = cassette.Record
+		}
+		recorder, err = cassette.New(settings.Api.Cassette, mode, nil)
+		if err != nil {
+			panic(err)
+		}
+		httpClient.Transport = recorder
+	}
+
+	if settings.Api.Url != "" {
+		clientOpts = append(clientOpts, api.WithBaseURL(settings.Api.Url))
+	}
+	if settings.Api.Rate != nil {
+		burst := settings.Api.Burst
+		if burst == 0 {
+			burst = api.DefaultBurst
+		}
+		clientOpts = append(clientOpts, api.WithRateLimit(*settings.Api.Rate, burst))
+	}
+	if settings.Api.Attempts > 0 {
+		policy := api.DefaultRetryPolicy
+		policy.MaxAttempts = settings.Api.Attempts
+		clientOpts = append(clientOpts, api.WithRetryPolicy(policy))
+	}
+	if settings.Api.Cache {
+		clientOpts = append(clientOpts, api.WithCache(nil, dbClient))
+	}
+	if settings.Metrics.Address == nil {
+		address := defaultMetricsAddress
+		settings.Metrics.Address = &address
+	}
+	if *settings.Metrics.Address != "" {
+		registry = metrics.New()
+		clientOpts = append(clientOpts, api.WithMetrics(registry))
+	}
+	gameClient = newGameClient(user)
+}
+
+// newGameClient creates an api client authenticated as user
+func newGameClient(user database.User) api.Client {
+	return api.New(user.Username, user.Token, &httpClient, logger, clientOpts...)
 }
 
 func main() {
-	waitWhileOffline()
+	if registry != nil {
+		go serveMetrics(*settings.Metrics.Address)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	waitWhileOffline(ctx)
+	stop()
+	if ctx.Err() != nil {
+		os.Exit(1)
+	}
+
 	startPrompts()
 }
 
@@ -101,6 +179,20 @@ type Settings struct {
 		Color bool   `json:"color"`
 		Level string `json:"level"`
 	} `json:"logging"`
+	Api struct {
+		Url      string   `json:"url"`
+		Rate     *float64 `json:"rate"`
+		Burst    int      `json:"burst"`
+		Attempts int      `json:"attempts"`
+		Cassette string   `json:"cassette"`
+		Record   bool     `json:"record"`
+		Cache    bool     `json:"cache"`
+	} `json:"api"`
+	Metrics struct {
+		// Address is defaultMetricsAddress when missing, empty disables
+		// the metrics
+		Address *string `json:"address"`
+	} `json:"metrics"`
 }
 
 type PrintFormater struct {
@@ -112,11 +204,15 @@ func (l PrintFormater) Printf(format string, a ...interface{}) {
 }
 
 func createLogfile(filename string) *os.File {
-	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0755)
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
 	if err != nil {
 		fmt.Printf("Could not create log file: %s.\n", err)
 		return nil
 	}
+	// log files from older versions were created executable and world-readable
+	if err := f.Chmod(0600); err != nil {
+		fmt.Printf("Could not restrict the log file permissions: %s.\n", err)
+	}
 
 	return f
 }
@@ -145,10 +241,41 @@ func loadSettings(path string, settings *Settings) error {
 	return nil
 }
 
-func waitWhileOffline() {
+// unlockDatabase asks for the passphrase of the saved tokens, it is read from
+// passphraseEnv when set.
+func unlockDatabase(c database.Client) (database.Client, error) {
+	if passphrase, ok := os.LookupEnv(passphraseEnv); ok {
+		return c.Unlock(passphrase)
+	}
+
+	for tries := 0; tries < 3; tries++ {
+		passphrase, err := readPassphrase("Passphrase of the saved tokens:")
+		if err != nil {
+			return c, err
+		}
+		unlocked, err := c.Unlock(passphrase)
+		if errors.Is(err, database.ErrWrongPassphrase) {
+			fmt.Println("Wrong passphrase, try again.")
+			continue
+		}
+		return unlocked, err
+	}
+	return c, database.ErrWrongPassphrase
+}
+
+// serveMetrics serves the api usage of gameClient at /metrics
+func serveMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	if err := http.ListenAndServe(address, mux); err != nil {
+		logger.Error("Serving metrics failed: ", err)
+	}
+}
+
+func waitWhileOffline(ctx context.Context) {
 	fmt.Println("Checking api status")
 	for {
-		status, err := gameClient.FetchStatus()
+		status, err := gameClient.FetchStatus(ctx)
 		if err != nil {
 			logger.Error(err)
 		}
@@ -158,6 +285,10 @@ func waitWhileOffline() {
 		}
 
 		fmt.Println("Waiting for api to come online")
-		time.Sleep(time.Second * 30)
+		select {
+		case <-time.After(time.Second * 30):
+		case <-ctx.Done():
+			return
+		}
 	}
 }
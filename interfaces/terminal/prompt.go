@@ -21,9 +21,13 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+
+	"golang.org/x/term"
 )
 
 func startPrompts() {
@@ -35,8 +39,12 @@ func startPrompts() {
 		scanner.Scan()
 		text := scanner.Text()
 
+		// Ctrl-C cancels the running command instead of exiting
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 		cmd := strings.Split(text, " ")
-		handleCmd(cmd)
+		handleCmd(ctx, cmd)
+		stop()
+		checkRecording()
 	}
 }
 
@@ -47,6 +55,20 @@ func checkAccount() {
 		fmt.Println("If you have an account, you can log in with \"account login <username> <token>\"")
 	} else {
 		fmt.Printf("You've logged in as %s\n", user.Username)
+		if users, err := dbClient.FetchUsers(); err == nil && len(users) > 1 {
+			fmt.Printf("%d accounts are saved, use \"account switch <username>\" to change account\n", len(users))
+		}
+	}
+}
+
+// checkRecording warns when api interactions could not be written to the
+// cassette, the commands themselves are unaffected.
+func checkRecording() {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Err(); err != nil {
+		fmt.Println("Recording api interactions failed:", err)
 	}
 }
 
@@ -72,3 +94,16 @@ func promptAndWait(message string) []string {
 	text := scanner.Text()
 	return strings.Split(text, " ")
 }
+
+// readPassphrase prompts for a passphrase without echoing it
+func readPassphrase(message string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("cannot prompt for a passphrase without a terminal, set %s instead", passphraseEnv)
+	}
+
+	fmt.Print(message + " ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Println()
+	return string(passphrase), err
+}
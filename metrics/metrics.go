@@ -0,0 +1,240 @@
+/**
+ * Copyright (C) 2021 Yi Fan Song <yfsong00@gmail.com>
+ *
+ * This file is part of space-kraken.
+ *
+ * space-kraken is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * space-kraken is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with space-kraken.  If not, see <https://www.gnu.org/licenses/>.
+ **/
+
+// Package metrics collects the api usage of space-kraken and serves it in the
+// prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// namespace prefixes the name of every metric
+const namespace = "space_kraken"
+
+// DefaultBuckets are the upper bounds in seconds of the latency histograms
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type requestKey struct {
+	method   string
+	endpoint string
+	status   string
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type account struct {
+	credits int64
+	ships   int
+}
+
+// Registry holds the metrics of an api client, it implements api.Metrics and
+// http.Handler.
+type Registry struct {
+	mu sync.Mutex
+
+	buckets       []float64
+	requests      map[requestKey]uint64
+	errors        map[string]uint64
+	latencies     map[string]*histogram
+	rateLimitWait struct {
+		count   uint64
+		seconds float64
+	}
+	accounts map[string]account
+}
+
+// New creates an empty registry using DefaultBuckets
+func New() *Registry {
+	return &Registry{
+		buckets:   DefaultBuckets,
+		requests:  map[requestKey]uint64{},
+		errors:    map[string]uint64{},
+		latencies: map[string]*histogram{},
+		accounts:  map[string]account{},
+	}
+}
+
+// ObserveRequest counts a request and records its latency
+func (r *Registry) ObserveRequest(method string, endpoint string, status int, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statusLabel := "none"
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	r.requests[requestKey{method, endpoint, statusLabel}]++
+
+	h, ok := r.latencies[endpoint]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(r.buckets))}
+		r.latencies[endpoint] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range r.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// ObserveError counts an error under code
+func (r *Registry) ObserveError(code string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors[code]++
+}
+
+// ObserveRateLimitWait counts a request delayed by the rate limiter
+func (r *Registry) ObserveRateLimitWait(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rateLimitWait.count++
+	r.rateLimitWait.seconds += d.Seconds()
+}
+
+// ObserveAccount sets the credits and ship count gauges of username
+func (r *Registry) ObserveAccount(username string, credits int64, ships int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.accounts[username] = account{credits, ships}
+}
+
+// ServeHTTP writes every metric in the prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// WriteTo writes every metric in the prometheus text format to w
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+
+	header(&b, "api_requests_total", "counter", "Requests sent to the api.")
+	requestKeys := make([]requestKey, 0, len(r.requests))
+	for key := range r.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, b := requestKeys[i], requestKeys[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	for _, key := range requestKeys {
+		sample(&b, "api_requests_total", labels("method", key.method, "endpoint", key.endpoint, "status", key.status), float64(r.requests[key]))
+	}
+
+	header(&b, "api_errors_total", "counter", "Failed api calls by game error code, http status or kind of failure.")
+	for _, code := range sortedKeys(r.errors) {
+		sample(&b, "api_errors_total", labels("code", code), float64(r.errors[code]))
+	}
+
+	header(&b, "api_request_duration_seconds", "histogram", "Latency of the requests sent to the api.")
+	endpoints := make([]string, 0, len(r.latencies))
+	for endpoint := range r.latencies {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		h := r.latencies[endpoint]
+		for i, bound := range r.buckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			sample(&b, "api_request_duration_seconds_bucket", labels("endpoint", endpoint, "le", le), float64(h.counts[i]))
+		}
+		sample(&b, "api_request_duration_seconds_bucket", labels("endpoint", endpoint, "le", "+Inf"), float64(h.count))
+		sample(&b, "api_request_duration_seconds_sum", labels("endpoint", endpoint), h.sum)
+		sample(&b, "api_request_duration_seconds_count", labels("endpoint", endpoint), float64(h.count))
+	}
+
+	header(&b, "api_rate_limit_waits_total", "counter", "Requests delayed by the rate limiter.")
+	sample(&b, "api_rate_limit_waits_total", "", float64(r.rateLimitWait.count))
+	header(&b, "api_rate_limit_wait_seconds_total", "counter", "Time spent waiting on the rate limiter.")
+	sample(&b, "api_rate_limit_wait_seconds_total", "", r.rateLimitWait.seconds)
+
+	usernames := make([]string, 0, len(r.accounts))
+	for username := range r.accounts {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	header(&b, "credits", "gauge", "Credits of the account when it was last fetched.")
+	for _, username := range usernames {
+		sample(&b, "credits", labels("username", username), float64(r.accounts[username].credits))
+	}
+	header(&b, "ships", "gauge", "Ships owned by the account when it was last fetched.")
+	for _, username := range usernames {
+		sample(&b, "ships", labels("username", username), float64(r.accounts[username].ships))
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func header(b *strings.Builder, name string, kind string, help string) {
+	fmt.Fprintf(b, "# HELP %s_%s %s\n", namespace, name, help)
+	fmt.Fprintf(b, "# TYPE %s_%s %s\n", namespace, name, kind)
+}
+
+func sample(b *strings.Builder, name string, labels string, value float64) {
+	fmt.Fprintf(b, "%s_%s%s %s\n", namespace, name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// labels formats pairs of label names and values, escaping the values
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", pairs[i], labelEscaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}